@@ -0,0 +1,170 @@
+package dashrates
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// fakeDoer answers every request with the same body, and status if it is
+// set or else 200.
+type fakeDoer struct {
+	body   []byte
+	status int
+}
+
+func (d fakeDoer) Do(req *http.Request) (*http.Response, error) {
+	status := d.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(bytes.NewReader(d.body)),
+		Request:    req,
+	}, nil
+}
+
+// fetchBody fetches from the registered adapter id with every response being
+// body, failing the test if it panics or returns a rate without a price.
+func fetchBody(t *testing.T, id string, body []byte) {
+	t.Helper()
+
+	factory, _ := Lookup(id)
+	api := factory(WithHTTPClient(fakeDoer{body: body}))
+
+	var (
+		ri  *RateInfo
+		err error
+	)
+	func() {
+		defer func() {
+			if r := recover(); r != nil {
+				t.Fatalf("%s panicked on %s: %v", id, body, r)
+			}
+		}()
+		ri, err = api.(ContextRateAPI).FetchRateContext(context.Background())
+	}()
+
+	if err == nil && (ri == nil || !ri.LastPriceDecimal.IsSet()) {
+		t.Fatalf("%s returned a rate without a price and no error for %s", id, body)
+	}
+}
+
+func TestAdaptersRejectMissingPrices(t *testing.T) {
+	bodies := map[string][]string{
+		"southxchange": {`{}`, `null`, `{"Bid":1}`},
+		"crex24":       {`[{}]`},
+		"huobi":        {`{"status":"ok","tick":{"data":[{}]}}`},
+		"triv":         {`[{"code":"DASH"}]`},
+		"digifinex":    {`{"ticker":[{}]}`},
+		"bittrex":      {`{"success":true,"result":[{}]}`},
+		"bitbns":       {`{"SYMBOL":{}}`},
+		"yobit":        {`{"SYMBOL":{}}`},
+	}
+	for id, cases := range bodies {
+		factory, _ := Lookup(id)
+		p := factory().(DescribedAPI).Describe().Pair
+		symbol := exchangeSymbol(id, string(p.Base), string(p.Quote))
+		for _, body := range cases {
+			fetchBody(t, id, []byte(strings.Replace(body, "SYMBOL", symbol, 1)))
+		}
+	}
+}
+
+// fuzzKeys are field names the adapters look for, so that random documents
+// get deep enough into the parsers to be interesting.
+var fuzzKeys = strings.Fields(`
+	Ask BaseVolume Bid High Last Low TimeStamp Volume Volume24Hr a ask
+	b bid buy c close code data date error h high last last_price
+	last_traded_price low message msg o open price rateUsd result
+	sell status success symbol t tick ticker time timestamp ts vol volume
+	err-code err-msg error_code`)
+
+// fuzzValue returns a random JSON value for a document for adapter id.
+func fuzzValue(r *rand.Rand, id string, depth int) interface{} {
+	n := 7
+	if depth > 3 {
+		n = 5
+	}
+	switch r.Intn(n) {
+	case 0:
+		return nil
+	case 1:
+		return r.Intn(2) == 0
+	case 2:
+		return []string{"0", "1", "-1", "12.5", "1e3", "abc", "", "ok", "DASH", "success"}[r.Intn(10)]
+	case 3:
+		return []interface{}{0, 1, -2.5, 1e300, 1577836800000}[r.Intn(5)]
+	case 4:
+		if depth > 3 {
+			return "x"
+		}
+		fallthrough
+	case 5:
+		arr := make([]interface{}, r.Intn(3))
+		for i := range arr {
+			arr[i] = fuzzValue(r, id, depth+1)
+		}
+		return arr
+	default:
+		obj := make(map[string]interface{})
+		for i := r.Intn(5); i > 0; i-- {
+			obj[fuzzKey(r, id)] = fuzzValue(r, id, depth+1)
+		}
+		return obj
+	}
+}
+
+// fuzzKey returns a random object key, sometimes the adapter's own symbol.
+func fuzzKey(r *rand.Rand, id string) string {
+	if r.Intn(4) == 0 {
+		factory, _ := Lookup(id)
+		if d, ok := factory().(DescribedAPI); ok {
+			p := d.Describe().Pair
+			return []string{
+				exchangeSymbol(id, string(p.Base), string(p.Quote)),
+				string(p.Base),
+				strings.ToLower(string(p.Base)),
+			}[r.Intn(3)]
+		}
+	}
+	return fuzzKeys[r.Intn(len(fuzzKeys))]
+}
+
+func TestAdaptersArbitraryResponses(t *testing.T) {
+	iterations := 2000
+	if testing.Short() {
+		iterations = 200
+	}
+
+	for _, id := range IDs() {
+		id := id
+		t.Run(id, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			for i := 0; i < iterations; i++ {
+				var body []byte
+				if i%10 == 0 {
+					// arbitrary bytes, not necessarily JSON
+					body = make([]byte, r.Intn(32))
+					r.Read(body)
+				} else {
+					var err error
+					body, err = json.Marshal(fuzzValue(r, id, 0))
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+				fetchBody(t, id, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package dashrates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// aggregatePlaces is the number of decimal places kept when an aggregate
+// involves division, which is plenty even for DASH/BTC prices.
+const aggregatePlaces = 12
+
+// ErrNotEnoughSources means too few sources gave a usable rate to aggregate.
+var ErrNotEnoughSources = errors.New("not enough sources")
+
+// Strategy combines the prices of several rates for the same pair into one.
+type Strategy interface {
+	Name() string
+	Aggregate(rates []*RateInfo) (Decimal, error)
+}
+
+// Median is a Strategy giving the median price. With an even number of rates
+// it is the mean of the middle two.
+type Median struct{}
+
+// Name is part of the Strategy interface implementation.
+func (Median) Name() string {
+	return "median"
+}
+
+// Aggregate is part of the Strategy interface implementation.
+func (Median) Aggregate(rates []*RateInfo) (Decimal, error) {
+	prices := sortedPrices(rates)
+	if len(prices) == 0 {
+		return Decimal{}, ErrNotEnoughSources
+	}
+	return medianOf(prices), nil
+}
+
+// TrimmedMean is a Strategy giving the mean price after dropping the highest
+// and lowest Fraction of rates, e.g. 0.2 drops the top and bottom 20%. The
+// number dropped from each end is rounded down, so with few rates nothing may
+// be dropped.
+type TrimmedMean struct {
+	Fraction float64
+}
+
+// Name is part of the Strategy interface implementation.
+func (t TrimmedMean) Name() string {
+	return fmt.Sprintf("trimmed mean (%g)", t.Fraction)
+}
+
+// Aggregate is part of the Strategy interface implementation.
+func (t TrimmedMean) Aggregate(rates []*RateInfo) (Decimal, error) {
+	if t.Fraction < 0 || t.Fraction >= 0.5 {
+		return Decimal{}, fmt.Errorf("trimmed mean fraction %g out of range [0, 0.5)", t.Fraction)
+	}
+	prices := sortedPrices(rates)
+	trim := int(float64(len(prices)) * t.Fraction)
+	prices = prices[trim : len(prices)-trim]
+	if len(prices) == 0 {
+		return Decimal{}, ErrNotEnoughSources
+	}
+
+	sum := NewDecimal(0, 0)
+	for _, p := range prices {
+		sum = sum.Add(p)
+	}
+	return sum.Div(NewDecimal(int64(len(prices)), 0), aggregatePlaces), nil
+}
+
+// NoVolumePolicy says what VolumeWeighted does with rates which don't report
+// a volume.
+type NoVolumePolicy int
+
+const (
+	// SkipNoVolume leaves rates without a volume out of the mean.
+	SkipNoVolume NoVolumePolicy = iota
+
+	// RejectNoVolume makes the whole aggregate fail if any rate lacks a
+	// volume.
+	RejectNoVolume
+)
+
+// VolumeWeighted is a Strategy giving the mean price weighted by each rate's
+// BaseAssetVolume. Rates whose volume is unset or zero, which includes every
+// rate from an adapter whose Description has HasVolume false, are handled as
+// NoVolume says.
+type VolumeWeighted struct {
+	NoVolume NoVolumePolicy
+}
+
+// Name is part of the Strategy interface implementation.
+func (VolumeWeighted) Name() string {
+	return "volume-weighted mean"
+}
+
+// Aggregate is part of the Strategy interface implementation.
+func (v VolumeWeighted) Aggregate(rates []*RateInfo) (Decimal, error) {
+	sum, volume := NewDecimal(0, 0), NewDecimal(0, 0)
+	for _, ri := range rates {
+		if !ri.LastPriceDecimal.IsSet() {
+			continue
+		}
+		if ri.BaseAssetVolumeDecimal.Sign() <= 0 {
+			if v.NoVolume == RejectNoVolume {
+				return Decimal{}, fmt.Errorf("%s/%s rate has no volume",
+					ri.BaseCurrency, ri.QuoteCurrency)
+			}
+			continue
+		}
+		sum = sum.Add(ri.LastPriceDecimal.Mul(ri.BaseAssetVolumeDecimal))
+		volume = volume.Add(ri.BaseAssetVolumeDecimal)
+	}
+	if volume.Sign() == 0 {
+		return Decimal{}, ErrNotEnoughSources
+	}
+	return sum.Div(volume, aggregatePlaces), nil
+}
+
+// sortedPrices returns the set prices of rates in ascending order.
+func sortedPrices(rates []*RateInfo) []Decimal {
+	prices := make([]Decimal, 0, len(rates))
+	for _, ri := range rates {
+		if ri.LastPriceDecimal.IsSet() {
+			prices = append(prices, ri.LastPriceDecimal)
+		}
+	}
+	return sortedDecimals(prices)
+}
+
+// sortedDecimals sorts ds in ascending order, returning it for convenience.
+func sortedDecimals(ds []Decimal) []Decimal {
+	sort.Slice(ds, func(i, j int) bool {
+		return ds[i].Cmp(ds[j]) < 0
+	})
+	return ds
+}
+
+// medianOf returns the median of sorted, which mustn't be empty.
+func medianOf(sorted []Decimal) Decimal {
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	// halving is exact, so no rounding is needed
+	return sorted[n/2-1].Add(sorted[n/2]).Mul(NewDecimal(5, -1))
+}
+
+// Exclusion records a source left out of an aggregate, and why.
+type Exclusion struct {
+	Exchange string
+	Reason   string
+}
+
+// Aggregate is the outcome of an Aggregator run: the combined rate along with
+// what went into it.
+type Aggregate struct {
+	Rate     *RateInfo
+	Strategy string
+
+	// Results holds every source's fetch result.
+	Results *ResultSet
+
+	// Used lists the exchanges whose rates went into Rate, and Excluded the
+	// ones which didn't.
+	Used     []string
+	Excluded []Exclusion
+
+	// Deviations gives each usable rate's distance from the cross-exchange
+	// median, when the Aggregator has an OutlierFilter.
+	Deviations []Deviation
+}
+
+// exclude records that exchange was left out of the aggregate.
+func (agg *Aggregate) exclude(exchange, reason string) {
+	agg.Excluded = append(agg.Excluded, Exclusion{Exchange: exchange, Reason: reason})
+}
+
+// Aggregator fetches the same pair from several sources and combines them
+// into a single reference rate using a Strategy. It implements RateAPI, so
+// it can be used anywhere a single exchange is.
+//
+// Sources whose fetch fails, which return a different pair, or which the
+// OutlierFilter rejects, are excluded from the aggregate. Wrap sources in a
+// StablecoinConverter to include them in a fiat pair.
+type Aggregator struct {
+	Name     string
+	Pair     Pair
+	APIs     []RateAPI
+	Strategy Strategy
+
+	// MinSources is the fewest usable rates to aggregate, after any outliers
+	// are rejected. Zero means 1.
+	MinSources int
+
+	// Outliers, if set, rejects rates too far from the cross-exchange
+	// median before they are aggregated.
+	Outliers *OutlierFilter
+
+	// FetchOptions is passed to FetchAll.
+	FetchOptions FetchAllOptions
+}
+
+// NewAggregator is a constructor for Aggregator.
+func NewAggregator(pair Pair, apis []RateAPI, strategy Strategy) *Aggregator {
+	return &Aggregator{
+		Name:     fmt.Sprintf("%s %s", pair, strategy.Name()),
+		Pair:     pair,
+		APIs:     apis,
+		Strategy: strategy,
+	}
+}
+
+// DisplayName returns the aggregator's name. It is part of the RateAPI
+// interface implementation.
+func (a *Aggregator) DisplayName() string {
+	return a.Name
+}
+
+// FetchRate fetches from every source and returns the aggregate rate.
+//
+// This is part of the RateAPI interface implementation.
+func (a *Aggregator) FetchRate() (*RateInfo, error) {
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext fetches from every source and returns the aggregate rate,
+// giving up when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *Aggregator) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	agg, err := a.Aggregate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return agg.Rate, nil
+}
+
+// Aggregate fetches from every source and combines the results, reporting
+// which sources were used.
+func (a *Aggregator) Aggregate(ctx context.Context) (*Aggregate, error) {
+	rs := FetchAll(ctx, a.APIs, a.FetchOptions)
+	return a.Combine(rs)
+}
+
+// Combine aggregates results which have already been fetched, e.g. by
+// FetchAll.
+func (a *Aggregator) Combine(rs *ResultSet) (*Aggregate, error) {
+	agg := &Aggregate{
+		Strategy: a.Strategy.Name(),
+		Results:  rs,
+	}
+
+	var usable []Result
+	for _, r := range rs.Results {
+		switch {
+		case r.Err != nil:
+			agg.exclude(r.Exchange, r.Err.Error())
+		case r.Rate == nil:
+			agg.exclude(r.Exchange, "no rate")
+		case r.Rate.BaseCurrency != string(a.Pair.Base) || r.Rate.QuoteCurrency != string(a.Pair.Quote):
+			agg.exclude(r.Exchange, fmt.Sprintf("rate is for %s/%s",
+				r.Rate.BaseCurrency, r.Rate.QuoteCurrency))
+		case !r.Rate.LastPriceDecimal.IsSet():
+			agg.exclude(r.Exchange, "no price")
+		default:
+			usable = append(usable, r)
+		}
+	}
+
+	if a.Outliers != nil {
+		usable = a.Outliers.filter(usable, agg)
+	}
+
+	rates := make([]*RateInfo, 0, len(usable))
+	for _, r := range usable {
+		agg.Used = append(agg.Used, r.Exchange)
+		rates = append(rates, r.Rate)
+	}
+
+	min := a.MinSources
+	if min < 1 {
+		min = 1
+	}
+	if len(rates) < min {
+		return nil, fmt.Errorf("%s: %w: %d usable of %d, need %d",
+			a.DisplayName(), ErrNotEnoughSources, len(rates), len(rs.Results), min)
+	}
+
+	price, err := a.Strategy.Aggregate(rates)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", a.DisplayName(), err)
+	}
+
+	volume := Decimal{}
+	for _, ri := range rates {
+		if !ri.BaseAssetVolumeDecimal.IsSet() {
+			continue
+		}
+		if !volume.IsSet() {
+			volume = NewDecimal(0, 0)
+		}
+		volume = volume.Add(ri.BaseAssetVolumeDecimal)
+	}
+
+	agg.Rate = &RateInfo{
+		BaseCurrency:           string(a.Pair.Base),
+		QuoteCurrency:          string(a.Pair.Quote),
+		LastPrice:              price.Float64(),
+		LastPriceDecimal:       price,
+		BaseAssetVolume:        volume.Float64(),
+		BaseAssetVolumeDecimal: volume,
+		FetchTime:              time.Now(),
+	}
+
+	return agg, nil
+}
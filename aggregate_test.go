@@ -0,0 +1,26 @@
+package dashrates
+
+import (
+	"testing"
+)
+
+func TestAggregatorCombineSkipsResultsWithoutRate(t *testing.T) {
+	rate := func(price int64) *RateInfo {
+		return &RateInfo{BaseCurrency: "DASH", QuoteCurrency: "USD", LastPriceDecimal: NewDecimal(price, 0)}
+	}
+	a := NewAggregator(NewPair("DASH", "USD"), nil, Median{})
+	agg, err := a.Combine(&ResultSet{Results: []Result{
+		{Exchange: "a", Rate: rate(100)},
+		{Exchange: "b"},
+		{Exchange: "c", Rate: rate(102)},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := agg.Rate.LastPriceDecimal.String(); got != "101.0" {
+		t.Errorf("price: got %s, want 101.0", got)
+	}
+	if len(agg.Excluded) != 1 || agg.Excluded[0] != (Exclusion{Exchange: "b", Reason: "no rate"}) {
+		t.Errorf("excluded: got %+v, want b for having no rate", agg.Excluded)
+	}
+}
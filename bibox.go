@@ -1,9 +1,7 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
 	"strconv"
 	"time"
 )
@@ -13,57 +11,109 @@ import (
 type BiboxAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("bibox", func(opts ...Option) RateAPI { return NewBiboxAPI(opts...) })
 }
 
 // NewBiboxAPI is a constructor for BiboxAPI.
-func NewBiboxAPI() *BiboxAPI {
+func NewBiboxAPI(opts ...Option) *BiboxAPI {
+	a, _ := NewBiboxAPIForPair("DASH", "BTC", opts...)
+	return a
+}
+
+// NewBiboxAPIForPair is a constructor for a BiboxAPI which fetches the rate for
+// the base/quote pair, e.g. ("DASH", "BTC"). It returns an error wrapping
+// ErrPairNotFound for pairs not in biboxPairs.
+func NewBiboxAPIForPair(base, quote string, opts ...Option) (*BiboxAPI, error) {
+	base, quote, err := checkPair("Bibox", biboxPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &BiboxAPI{
 		BaseAPIURL:          "https://api.bibox.com",
-		PriceTickerEndpoint: "/v1/mdata?cmd=market&pair=DASH_BTC",
-	}
+		PriceTickerEndpoint: "/v1/mdata?cmd=market&pair=" + exchangeSymbol("bibox", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// biboxPairs lists the Bibox markets which NewBiboxAPIForPair accepts.
+var biboxPairs = []string{"DASH/BTC", "DASH/USDT"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *BiboxAPI) DisplayName() string {
 	return "Bibox"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *BiboxAPI) Describe() Description {
+	return Description{
+		ID:        "bibox",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(biboxPairs),
+		Price:     LastTradePrice,
+		HasVolume: true,
+		URL:       "https://www.bibox.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Bibox API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *BiboxAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the Bibox API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *BiboxAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res biboxPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	data, err := res.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
-	// parse json and extract Dash rate
-	var res biboxPubTickerResp
-	err = json.Unmarshal(body, &res)
+	last, err := ParseDecimal(res.Result.Last)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
-
-	data, err := res.Normalize()
+	volume, err := ParseDecimal(res.Result.Vol24h)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "BTC",
-		LastPrice:       data.Last,
-		BaseAssetVolume: data.Vol24h,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              data.Last,
+		LastPriceDecimal:       last,
+		BaseAssetVolume:        data.Vol24h,
+		BaseAssetVolumeDecimal: volume,
+		High:                   optionalDecimal(res.Result.High),
+		Low:                    optionalDecimal(res.Result.Low),
+		QuoteAssetVolume:       optionalDecimal(res.Result.Amount),
+		FetchTime:              now,
 	}
 
 	return &ri, nil
@@ -1,9 +1,7 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
 	"strconv"
 	"time"
 )
@@ -13,57 +11,119 @@ import (
 type BigONEAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("bigone", func(opts ...Option) RateAPI { return NewBigONEAPI(opts...) })
 }
 
 // NewBigONEAPI is a constructor for BigONEAPI.
-func NewBigONEAPI() *BigONEAPI {
+func NewBigONEAPI(opts ...Option) *BigONEAPI {
+	a, _ := NewBigONEAPIForPair("DASH", "BTC", opts...)
+	return a
+}
+
+// NewBigONEAPIForPair is a constructor for a BigONEAPI which fetches the rate
+// for the base/quote pair, e.g. ("DASH", "BTC"). It returns an error wrapping
+// ErrPairNotFound for pairs not in bigONEPairs.
+func NewBigONEAPIForPair(base, quote string, opts ...Option) (*BigONEAPI, error) {
+	base, quote, err := checkPair("BigONE", bigONEPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &BigONEAPI{
 		BaseAPIURL:          "https://big.one/api/v3",
-		PriceTickerEndpoint: "/asset_pairs/DASH-BTC/ticker",
-	}
+		PriceTickerEndpoint: "/asset_pairs/" + exchangeSymbol("bigone", base, quote) + "/ticker",
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// bigONEPairs lists the BigONE markets which NewBigONEAPIForPair accepts.
+var bigONEPairs = []string{"DASH/BTC", "DASH/USDT"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *BigONEAPI) DisplayName() string {
 	return "BigONE"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *BigONEAPI) Describe() Description {
+	return Description{
+		ID:        "bigone",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(bigONEPairs),
+		Price:     AskPrice,
+		HasVolume: true,
+		URL:       "https://big.one",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the BigONE API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *BigONEAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the BigONE API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *BigONEAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res bigONEPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if res.Code != 0 {
+		return nil, &ExchangeError{
+			Exchange: a.DisplayName(),
+			Code:     strconv.Itoa(res.Code),
+			Msg:      res.Message,
+		}
 	}
 
-	// parse json and extract Dash rate
-	var res bigONEPubTickerResp
-	err = json.Unmarshal(body, &res)
+	data, err := res.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
-	data, err := res.Normalize()
+	last, err := ParseDecimal(res.Data.Ask.Price)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
+	}
+	volume, err := ParseDecimal(res.Data.Volume)
+	if err != nil {
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "BTC",
-		LastPrice:       data.Data.Ask.Price,
-		BaseAssetVolume: data.Data.Volume,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              data.Data.Ask.Price,
+		LastPriceDecimal:       last,
+		BaseAssetVolume:        data.Data.Volume,
+		BaseAssetVolumeDecimal: volume,
+		Bid:                    optionalDecimal(res.Data.Bid.Price),
+		Ask:                    optionalDecimal(res.Data.Ask.Price),
+		High:                   optionalDecimal(res.Data.High),
+		Low:                    optionalDecimal(res.Data.Low),
+		Open:                   optionalDecimal(res.Data.Open),
+		FetchTime:              now,
 	}
 
 	return &ri, nil
@@ -71,8 +131,9 @@ func (a *BigONEAPI) FetchRate() (*RateInfo, error) {
 
 // bigONEPubTickerResp is used in parsing the BigONE API response only.
 type bigONEPubTickerResp struct {
-	Code int `json:"code"`
-	Data struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Data    struct {
 		AssetPairName string              `json:"asset_pair_name"`
 		Bid           bigONEBidAskStrings `json:"bid"`
 		Ask           bigONEBidAskStrings `json:"ask"`
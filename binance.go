@@ -1,9 +1,9 @@
 package dashrates
 
 import (
+	"context"
 	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"errors"
 	"strconv"
 	"time"
 )
@@ -13,61 +13,135 @@ import (
 type BinanceAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("binance", func(opts ...Option) RateAPI { return NewBinanceAPI(opts...) })
 }
 
 // NewBinanceAPI is a constructor for BinanceAPI.
-func NewBinanceAPI() *BinanceAPI {
+func NewBinanceAPI(opts ...Option) *BinanceAPI {
+	a, _ := NewBinanceAPIForPair("DASH", "BTC", opts...)
+	return a
+}
+
+// NewBinanceAPIForPair is a constructor for a BinanceAPI which fetches the rate
+// for the base/quote pair, e.g. ("DASH", "BTC"). It returns an error wrapping
+// ErrPairNotFound for pairs not in binancePairs.
+func NewBinanceAPIForPair(base, quote string, opts ...Option) (*BinanceAPI, error) {
+	base, quote, err := checkPair("Binance", binancePairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &BinanceAPI{
 		BaseAPIURL:          "https://api.binance.com",
-		PriceTickerEndpoint: "/api/v3/ticker/price?symbol=DASHBTC",
-	}
+		PriceTickerEndpoint: "/api/v3/ticker/price?symbol=" + exchangeSymbol("binance", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// binancePairs lists the Binance markets which NewBinanceAPIForPair accepts.
+var binancePairs = []string{"DASH/BTC", "DASH/ETH", "DASH/USDT", "DASH/BNB", "DASH/BUSD"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *BinanceAPI) DisplayName() string {
 	return "Binance"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *BinanceAPI) Describe() Description {
+	return Description{
+		ID:    "binance",
+		Name:  a.DisplayName(),
+		Pair:  NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs: parsePairs(binancePairs),
+		Price: LastTradePrice,
+		URL:   "https://www.binance.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Binance API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *BinanceAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	now := time.Now()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+	return a.FetchRateContext(context.Background())
+}
 
+// FetchRateContext gets the Dash exchange rate from the Binance API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *BinanceAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
 	// parse json and extract Dash rate
 	var res binancePriceResp
-	err = json.Unmarshal(body, &res)
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return nil, binanceError(a.DisplayName(), httpErr)
+	}
 	if err != nil {
 		return nil, err
 	}
-	price, err := strconv.ParseFloat(res.Price, 64)
+
+	now := time.Now()
+	price, err := ParseDecimal(res.Price)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "BTC",
-		LastPrice:       price,
-		BaseAssetVolume: 0,
-		FetchTime:       now,
+		BaseCurrency:     a.BaseCurrency,
+		QuoteCurrency:    a.QuoteCurrency,
+		LastPrice:        price.Float64(),
+		LastPriceDecimal: price,
+		BaseAssetVolume:  0,
+		FetchTime:        now,
 	}
 
 	return &ri, nil
 }
 
+// binanceInvalidSymbol is the code Binance sends, with a 400 status, for a
+// symbol it doesn't list.
+const binanceInvalidSymbol = -1121
+
+// binanceError converts a failed Binance response into an *ExchangeError if
+// its body is one of Binance's error objects, or else returns it unchanged.
+// Error codes the package doesn't know keep the HTTPError's classification.
+func binanceError(exchange string, httpErr *HTTPError) error {
+	var res binanceErrorResp
+	if err := json.Unmarshal(httpErr.Body, &res); err != nil || res.Code == 0 {
+		return httpErr
+	}
+
+	var class error = httpErr
+	if res.Code == binanceInvalidSymbol {
+		class = ErrPairNotFound
+	}
+
+	return &ExchangeError{
+		Exchange: exchange,
+		Code:     strconv.FormatInt(res.Code, 10),
+		Msg:      res.Msg,
+		Err:      class,
+	}
+}
+
+// binanceErrorResp is used in parsing Binance error responses only.
+type binanceErrorResp struct {
+	Code int64  `json:"code"`
+	Msg  string `json:"msg"`
+}
+
 // binancePriceResp is used in parsing the Binance API response only.
 type binancePriceResp struct {
 	Symbol string `json:"symbol"`
@@ -1,9 +1,8 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
+	"errors"
 	"time"
 )
 
@@ -12,65 +11,113 @@ import (
 type BitbnsAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("bitbns", func(opts ...Option) RateAPI { return NewBitbnsAPI(opts...) })
 }
 
 // NewBitbnsAPI is a constructor for BitbnsAPI.
-func NewBitbnsAPI() *BitbnsAPI {
+func NewBitbnsAPI(opts ...Option) *BitbnsAPI {
+	a, _ := NewBitbnsAPIForPair("DASH", "USDT", opts...)
+	return a
+}
+
+// NewBitbnsAPIForPair is a constructor for a BitbnsAPI which fetches the rate
+// for the base/quote pair, e.g. ("DASH", "USDT"). It returns an error wrapping
+// ErrPairNotFound for pairs not in bitbnsPairs.
+func NewBitbnsAPIForPair(base, quote string, opts ...Option) (*BitbnsAPI, error) {
+	base, quote, err := checkPair("Bitbns", bitbnsPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &BitbnsAPI{
 		BaseAPIURL:          "https://bitbns.com",
 		PriceTickerEndpoint: "/order/getTickerWithVolume/",
-	}
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// bitbnsPairs lists the Bitbns markets which NewBitbnsAPIForPair accepts.
+var bitbnsPairs = []string{"DASH/USDT", "DASH/INR"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *BitbnsAPI) DisplayName() string {
 	return "Bitbns"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *BitbnsAPI) Describe() Description {
+	return Description{
+		ID:    "bitbns",
+		Name:  a.DisplayName(),
+		Pair:  NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs: parsePairs(bitbnsPairs),
+		Price: LastTradePrice,
+		URL:   "https://bitbns.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Bitbns API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *BitbnsAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the Bitbns API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *BitbnsAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res map[string]bitbnsTicker
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	ticker, ok := res[exchangeSymbol("bitbns", a.BaseCurrency, a.QuoteCurrency)]
+	if !ok {
+		return nil, pairNotFound(a.DisplayName(), a.BaseCurrency, a.QuoteCurrency)
 	}
 
-	// parse json and extract Dash rate
-	var res bitbnsPriceResp
-	err = json.Unmarshal(body, &res)
-	if err != nil {
-		return nil, err
+	if !ticker.LastTradedPrice.IsSet() {
+		return nil, badResponse(errors.New("no price in response"))
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "USD",
-		LastPrice:       res.Dashusdt.LastTradedPrice,
-		BaseAssetVolume: 0,
-		FetchTime:       now,
+		BaseCurrency:     a.BaseCurrency,
+		QuoteCurrency:    a.QuoteCurrency,
+		LastPrice:        ticker.LastTradedPrice.Float64(),
+		LastPriceDecimal: ticker.LastTradedPrice,
+		BaseAssetVolume:  0,
+		Bid:              ticker.HighestBuyBid,
+		Ask:              ticker.LowestSellBid,
+		FetchTime:        now,
 	}
 
 	return &ri, nil
 }
 
-// bitbnsPriceResp is used in parsing the Bitbns API response only.
-type bitbnsPriceResp struct {
-	Dashusdt struct {
-		HighestBuyBid   float64 `json:"highest_buy_bid"`
-		LowestSellBid   float64 `json:"lowest_sell_bid"`
-		LastTradedPrice float64 `json:"last_traded_price"`
-		YesPrice        float64 `json:"yes_price"`
-		InrPrice        float64 `json:"inr_price"`
-		Volume          struct{}
-	} `json:"DASHUSDT"`
+// bitbnsTicker is used in parsing the Bitbns API response only. The response
+// maps each market's symbol to one of these.
+type bitbnsTicker struct {
+	HighestBuyBid   Decimal `json:"highest_buy_bid"`
+	LowestSellBid   Decimal `json:"lowest_sell_bid"`
+	LastTradedPrice Decimal `json:"last_traded_price"`
+	YesPrice        float64 `json:"yes_price"`
+	InrPrice        float64 `json:"inr_price"`
+	Volume          struct{}
 }
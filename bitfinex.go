@@ -1,10 +1,8 @@
 package dashrates
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"net/http"
 	"strconv"
 	"strings"
 	"time"
@@ -15,57 +13,111 @@ import (
 type BitfinexAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("bitfinex", func(opts ...Option) RateAPI { return NewBitfinexAPI(opts...) })
 }
 
 // NewBitfinexAPI is a constructor for BitfinexAPI.
-func NewBitfinexAPI() *BitfinexAPI {
+func NewBitfinexAPI(opts ...Option) *BitfinexAPI {
+	a, _ := NewBitfinexAPIForPair("DASH", "USD", opts...)
+	return a
+}
+
+// NewBitfinexAPIForPair is a constructor for a BitfinexAPI which fetches the
+// rate for the base/quote pair, e.g. ("DASH", "USD"). It returns an error
+// wrapping ErrPairNotFound for pairs not in bitfinexPairs.
+func NewBitfinexAPIForPair(base, quote string, opts ...Option) (*BitfinexAPI, error) {
+	base, quote, err := checkPair("Bitfinex", bitfinexPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &BitfinexAPI{
 		BaseAPIURL:          "https://api.bitfinex.com",
-		PriceTickerEndpoint: "/v1/pubticker/dshusd",
-	}
+		PriceTickerEndpoint: "/v1/pubticker/" + exchangeSymbol("bitfinex", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// bitfinexPairs lists the Bitfinex markets which NewBitfinexAPIForPair accepts.
+var bitfinexPairs = []string{"DASH/USD", "DASH/BTC"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *BitfinexAPI) DisplayName() string {
 	return "Bitfinex"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *BitfinexAPI) Describe() Description {
+	return Description{
+		ID:        "bitfinex",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(bitfinexPairs),
+		Price:     LastTradePrice,
+		HasVolume: true,
+		URL:       "https://www.bitfinex.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Bitfinex API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *BitfinexAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the Bitfinex API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *BitfinexAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res bitfinexPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	data, err := res.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
-	// parse json and extract Dash rate
-	var res bitfinexPubTickerResp
-	err = json.Unmarshal(body, &res)
+	last, err := ParseDecimal(res.LastPrice)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
-
-	data, err := res.Normalize()
+	volume, err := ParseDecimal(res.Volume)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "USD",
-		LastPrice:       data.LastPrice,
-		BaseAssetVolume: data.Volume,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              data.LastPrice,
+		LastPriceDecimal:       last,
+		BaseAssetVolume:        data.Volume,
+		BaseAssetVolumeDecimal: volume,
+		Bid:                    optionalDecimal(res.Bid),
+		Ask:                    optionalDecimal(res.Ask),
+		High:                   optionalDecimal(res.High),
+		Low:                    optionalDecimal(res.Low),
+		FetchTime:              now,
+		ServerTime:             data.Timestamp,
 	}
 
 	return &ri, nil
@@ -141,7 +193,10 @@ func (resp *bitfinexPubTickerResp) Normalize() (*bitfinexTickerData, error) {
 	if err != nil {
 		return nil, err
 	}
-	nsec, err := strconv.ParseInt(parts[1], 10, 64)
+	// The fractional part is a decimal fraction of a second, so pad or cut it
+	// to nanosecond precision before parsing.
+	frac := (parts[1] + "000000000")[:9]
+	nsec, err := strconv.ParseInt(frac, 10, 64)
 	if err != nil {
 		return nil, err
 	}
@@ -1,9 +1,8 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
+	"errors"
 	"time"
 )
 
@@ -13,53 +12,119 @@ type BittrexAPI struct {
 	BaseAPIURL            string
 	PriceTickerEndpoint   string
 	MarketSummaryEndpoint string
+	BaseCurrency          string
+	QuoteCurrency         string
+	Client                Doer
+}
+
+func init() {
+	Register("bittrex", func(opts ...Option) RateAPI { return NewBittrexAPI(opts...) })
 }
 
 // NewBittrexAPI is a constructor for BittrexAPI.
-func NewBittrexAPI() *BittrexAPI {
+func NewBittrexAPI(opts ...Option) *BittrexAPI {
+	a, _ := NewBittrexAPIForPair("DASH", "BTC", opts...)
+	return a
+}
+
+// NewBittrexAPIForPair is a constructor for a BittrexAPI which fetches the rate
+// for the base/quote pair, e.g. ("DASH", "BTC"). It returns an error wrapping
+// ErrPairNotFound for pairs not in bittrexPairs.
+func NewBittrexAPIForPair(base, quote string, opts ...Option) (*BittrexAPI, error) {
+	base, quote, err := checkPair("Bittrex", bittrexPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &BittrexAPI{
 		BaseAPIURL:            "https://api.bittrex.com",
-		PriceTickerEndpoint:   "/api/v1.1/public/getticker?market=BTC-DASH",
-		MarketSummaryEndpoint: "/api/v1.1/public/getmarketsummary?market=btc-dash",
-	}
+		PriceTickerEndpoint:   "/api/v1.1/public/getticker?market=" + exchangeSymbol("bittrex", base, quote),
+		MarketSummaryEndpoint: "/api/v1.1/public/getmarketsummary?market=" + exchangeSymbol("bittrex", base, quote),
+		BaseCurrency:          base,
+		QuoteCurrency:         quote,
+		Client:                o.client,
+	}, nil
 }
 
+// bittrexPairs lists the Bittrex markets which NewBittrexAPIForPair accepts.
+var bittrexPairs = []string{"DASH/BTC", "DASH/ETH", "DASH/USDT"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *BittrexAPI) DisplayName() string {
 	return "Bittrex"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *BittrexAPI) Describe() Description {
+	return Description{
+		ID:         "bittrex",
+		Name:       a.DisplayName(),
+		Pair:       NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:      parsePairs(bittrexPairs),
+		Price:      LastTradePrice,
+		HasVolume:  true,
+		URL:        "https://bittrex.com",
+		Deprecated: "Bittrex Global closed in December 2023.",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Bittrex API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *BittrexAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.MarketSummaryEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the Bittrex API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *BittrexAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res bittrexPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.MarketSummaryEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if !res.Success {
+		var class error
+		if res.Message == "INVALID_MARKET" {
+			class = ErrPairNotFound
+		}
+		return nil, &ExchangeError{
+			Exchange: a.DisplayName(),
+			Msg:      res.Message,
+			Err:      class,
+		}
 	}
 
-	// parse json and extract Dash rate
-	var res bittrexPubTickerResp
-	err = json.Unmarshal(body, &res)
-	if err != nil {
-		return nil, err
+	if len(res.Result) == 0 {
+		return nil, badResponse(errors.New("no market summaries in response"))
+	}
+
+	if !res.Result[0].Last.IsSet() {
+		return nil, badResponse(errors.New("no price in response"))
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "BTC",
-		LastPrice:       res.Result[0].Last,
-		BaseAssetVolume: res.Result[0].BaseVolume,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              res.Result[0].Last.Float64(),
+		LastPriceDecimal:       res.Result[0].Last,
+		BaseAssetVolume:        res.Result[0].BaseVolume.Float64(),
+		BaseAssetVolumeDecimal: res.Result[0].BaseVolume,
+		Bid:                    res.Result[0].Bid,
+		Ask:                    res.Result[0].Ask,
+		High:                   res.Result[0].High,
+		Low:                    res.Result[0].Low,
+		QuoteAssetVolume:       res.Result[0].QuoteVolume,
+		FetchTime:              now,
 	}
 
 	return &ri, nil
@@ -93,13 +158,13 @@ type bittrexPriceTickerResult struct {
 // worry about this.
 type bittrexMarketSummaryResult struct {
 	MarketName     string  `json:"MarketName"`
-	High           float64 `json:"High"`
-	Low            float64 `json:"Low"`
-	QuoteVolume    float64 `json:"BaseVolume"`
-	Last           float64 `json:"Last"`
-	BaseVolume     float64 `json:"Volume"`
-	Bid            float64 `json:"Bid"`
-	Ask            float64 `json:"Ask"`
+	High           Decimal `json:"High"`
+	Low            Decimal `json:"Low"`
+	QuoteVolume    Decimal `json:"BaseVolume"`
+	Last           Decimal `json:"Last"`
+	BaseVolume     Decimal `json:"Volume"`
+	Bid            Decimal `json:"Bid"`
+	Ask            Decimal `json:"Ask"`
 	OpenBuyOrders  int     `json:"OpenBuyOrders"`
 	OpenSellOrders int     `json:"OpenSellOrders"`
 	PrevDay        float64 `json:"PrevDay"`
@@ -1,9 +1,7 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
 	"strconv"
 	"time"
 )
@@ -13,57 +11,119 @@ import (
 type BvnexAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("bvnex", func(opts ...Option) RateAPI { return NewBvnexAPI(opts...) })
 }
 
 // NewBvnexAPI is a constructor for BvnexAPI.
-func NewBvnexAPI() *BvnexAPI {
+func NewBvnexAPI(opts ...Option) *BvnexAPI {
+	a, _ := NewBvnexAPIForPair("DASH", "USDT", opts...)
+	return a
+}
+
+// NewBvnexAPIForPair is a constructor for a BvnexAPI which fetches the rate for
+// the base/quote pair, e.g. ("DASH", "USDT"). It returns an error wrapping
+// ErrPairNotFound for pairs not in bvnexPairs.
+func NewBvnexAPIForPair(base, quote string, opts ...Option) (*BvnexAPI, error) {
+	base, quote, err := checkPair("Bvnex", bvnexPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &BvnexAPI{
 		BaseAPIURL:          "https://api.bvnex.com",
-		PriceTickerEndpoint: "/api/ticker/get?symbol=dash_usdt",
-	}
+		PriceTickerEndpoint: "/api/ticker/get?symbol=" + exchangeSymbol("bvnex", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// bvnexPairs lists the Bvnex markets which NewBvnexAPIForPair accepts.
+var bvnexPairs = []string{"DASH/USDT", "DASH/BTC"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *BvnexAPI) DisplayName() string {
 	return "Bvnex"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *BvnexAPI) Describe() Description {
+	return Description{
+		ID:        "bvnex",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(bvnexPairs),
+		Price:     LastTradePrice,
+		HasVolume: true,
+		URL:       "https://www.bvnex.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Bvnex API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *BvnexAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the Bvnex API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *BvnexAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res bvnexPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if res.Code != 0 {
+		return nil, &ExchangeError{
+			Exchange: a.DisplayName(),
+			Code:     strconv.Itoa(res.Code),
+			Msg:      res.Msg,
+		}
 	}
 
-	// parse json and extract Dash rate
-	var res bvnexPubTickerResp
-	err = json.Unmarshal(body, &res)
+	data, err := res.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
-	data, err := res.Normalize()
+	last, err := ParseDecimal(res.Data.Last)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
+	}
+	volume, err := ParseDecimal(res.Data.BaseVolume)
+	if err != nil {
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "USD",
-		LastPrice:       data.Last,
-		BaseAssetVolume: data.BaseVolume,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              data.Last,
+		LastPriceDecimal:       last,
+		BaseAssetVolume:        data.BaseVolume,
+		BaseAssetVolumeDecimal: volume,
+		Bid:                    optionalDecimal(res.Data.HighestBid),
+		Ask:                    optionalDecimal(res.Data.LowestAsk),
+		High:                   optionalDecimal(res.Data.High24hr),
+		Low:                    optionalDecimal(res.Data.Low24hr),
+		QuoteAssetVolume:       optionalDecimal(res.Data.QuoteVolume),
+		FetchTime:              now,
 	}
 
 	return &ri, nil
@@ -0,0 +1,270 @@
+package dashrates
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheEntry is a cached rate along with when it was stored.
+type CacheEntry struct {
+	Rate   *RateInfo
+	Stored time.Time
+}
+
+// Cache stores rates for a CachedAPI. Implementations must be safe for
+// concurrent use.
+type Cache interface {
+	Get(key string) (CacheEntry, bool)
+	Set(key string, entry CacheEntry)
+}
+
+// MemoryCache is an in-memory Cache. Entries are kept until overwritten, as
+// the CachedAPI decides what is too old to use.
+type MemoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]CacheEntry
+}
+
+// NewMemoryCache is a constructor for MemoryCache.
+func NewMemoryCache() *MemoryCache {
+	return &MemoryCache{entries: make(map[string]CacheEntry)}
+}
+
+// Get is part of the Cache interface implementation.
+func (c *MemoryCache) Get(key string) (CacheEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[key]
+	return e, ok
+}
+
+// Set is part of the Cache interface implementation.
+func (c *MemoryCache) Set(key string, entry CacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[key] = entry
+}
+
+// CacheStats counts how a CachedAPI's fetches were served.
+type CacheStats struct {
+	// Hits were served from a fresh cache entry, and StaleHits from an
+	// expired one within the stale-while-revalidate window.
+	Hits      uint64
+	StaleHits uint64
+
+	// Misses had to wait for the underlying API.
+	Misses uint64
+
+	// RefreshErrors counts background refreshes which failed.
+	RefreshErrors uint64
+}
+
+// CachedRate is a rate served by a CachedAPI, with where it came from.
+type CachedRate struct {
+	Rate *RateInfo
+
+	// Age is how long ago the rate was fetched from the underlying API.
+	Age time.Duration
+
+	// Hit reports whether the rate came from the cache, and Stale whether
+	// it had expired.
+	Hit   bool
+	Stale bool
+}
+
+// CachedAPI wraps a RateAPI, serving rates from a Cache for TTL after they
+// were fetched, so that frequent callers don't get rate limited.
+//
+// With StaleWhileRevalidate set, an expired rate is still served for up to
+// that long past its TTL, while a fresh one is fetched in the background.
+// Otherwise, and once that window has passed, callers wait for a new fetch.
+// Failed fetches aren't cached.
+type CachedAPI struct {
+	API                  RateAPI
+	Cache                Cache
+	Key                  string
+	TTL                  time.Duration
+	StaleWhileRevalidate time.Duration
+
+	hits, staleHits, misses, refreshErrors uint64
+
+	// fetching holds a token while a caller fetches from the API. It is a
+	// channel rather than a mutex so that waiting callers can give up.
+	fetchingOnce sync.Once
+	fetching     chan struct{}
+	refreshing   int32
+}
+
+// NewCachedAPI is a constructor for CachedAPI. The cache key is built from
+// what api fetches, so that APIs sharing a cache don't serve each other's
+// rates: an adapter's ID and pair if it is a DescribedAPI, and for the
+// package's converters, cross rates and aggregators, the keys of the APIs
+// they wrap along with their pair or target currency. Any other API is keyed
+// by its display name, so set Key if two of those share a cache and a name.
+func NewCachedAPI(api RateAPI, cache Cache, ttl time.Duration) *CachedAPI {
+	return &CachedAPI{
+		API:   api,
+		Cache: cache,
+		Key:   cacheKey(api),
+		TTL:   ttl,
+	}
+}
+
+// cacheKey returns the default cache key for api, as described for
+// NewCachedAPI.
+func cacheKey(api RateAPI) string {
+	switch a := api.(type) {
+	case DescribedAPI:
+		desc := a.Describe()
+		return desc.ID + ":" + desc.Pair.String()
+	case *CachedAPI:
+		return cacheKey(a.API)
+	case *FXConverter:
+		return fmt.Sprintf("%s>%s:%s", cacheKey(a.API), a.Source.DisplayName(), a.To)
+	case *StablecoinConverter:
+		quotes := make([]string, 0, len(a.Sources))
+		for quote, src := range a.Sources {
+			quotes = append(quotes, fmt.Sprintf("%s=%s", quote, cacheKey(src)))
+		}
+		sort.Strings(quotes)
+		return fmt.Sprintf("%s>stablecoins(%s)", cacheKey(a.API), strings.Join(quotes, ","))
+	case *CrossRateAPI:
+		return fmt.Sprintf("cross:%s(%s)", a.Pair, cacheKeys(a.APIs))
+	case *Aggregator:
+		return fmt.Sprintf("aggregate:%s:%s:%s(%s)", a.Name, a.Pair, a.Strategy.Name(), cacheKeys(a.APIs))
+	}
+	return api.DisplayName()
+}
+
+// cacheKeys returns the cache keys of apis, joined by commas.
+func cacheKeys(apis []RateAPI) string {
+	keys := make([]string, len(apis))
+	for i, api := range apis {
+		keys[i] = cacheKey(api)
+	}
+	return strings.Join(keys, ",")
+}
+
+// DisplayName returns the display name of the wrapped API. It is part of the
+// RateAPI interface implementation.
+func (c *CachedAPI) DisplayName() string {
+	return c.API.DisplayName()
+}
+
+// FetchRate gets the rate from the cache, or the wrapped API.
+//
+// This is part of the RateAPI interface implementation.
+func (c *CachedAPI) FetchRate() (*RateInfo, error) {
+	return c.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the rate from the cache, or the wrapped API, giving
+// up when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (c *CachedAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	cr, err := c.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return cr.Rate, nil
+}
+
+// Fetch is like FetchRateContext, but also reports whether the rate came
+// from the cache and how old it is.
+func (c *CachedAPI) Fetch(ctx context.Context) (*CachedRate, error) {
+	if cr, ok := c.cached(); ok {
+		return cr, nil
+	}
+
+	// only one caller fetches at a time; the rest wait and use its result,
+	// unless their ctx is done first
+	c.fetchingOnce.Do(func() {
+		c.fetching = make(chan struct{}, 1)
+	})
+	select {
+	case c.fetching <- struct{}{}:
+	case <-ctx.Done():
+		return nil, unavailable(ctx.Err())
+	}
+	defer func() { <-c.fetching }()
+
+	if cr, ok := c.cached(); ok {
+		return cr, nil
+	}
+
+	atomic.AddUint64(&c.misses, 1)
+	ri, err := fetchRate(ctx, c.API)
+	if err != nil {
+		return nil, err
+	}
+	c.Cache.Set(c.Key, CacheEntry{Rate: ri, Stored: time.Now()})
+
+	return &CachedRate{Rate: copyRate(ri)}, nil
+}
+
+// Stats returns how fetches have been served so far.
+func (c *CachedAPI) Stats() CacheStats {
+	return CacheStats{
+		Hits:          atomic.LoadUint64(&c.hits),
+		StaleHits:     atomic.LoadUint64(&c.staleHits),
+		Misses:        atomic.LoadUint64(&c.misses),
+		RefreshErrors: atomic.LoadUint64(&c.refreshErrors),
+	}
+}
+
+// cached returns the cached rate if it is fresh enough to serve, starting a
+// background refresh if it has expired.
+func (c *CachedAPI) cached() (*CachedRate, bool) {
+	e, ok := c.Cache.Get(c.Key)
+	if !ok || e.Rate == nil {
+		return nil, false
+	}
+
+	age := time.Since(e.Stored)
+	cr := &CachedRate{Rate: copyRate(e.Rate), Age: age, Hit: true}
+	switch {
+	case age < c.TTL:
+		atomic.AddUint64(&c.hits, 1)
+	case age < c.TTL+c.StaleWhileRevalidate:
+		atomic.AddUint64(&c.staleHits, 1)
+		cr.Stale = true
+		c.refresh()
+	default:
+		return nil, false
+	}
+	return cr, true
+}
+
+// refresh fetches a new rate in the background, unless that is already
+// happening.
+func (c *CachedAPI) refresh() {
+	if !atomic.CompareAndSwapInt32(&c.refreshing, 0, 1) {
+		return
+	}
+	go func() {
+		defer atomic.StoreInt32(&c.refreshing, 0)
+
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultFetchTimeout)
+		defer cancel()
+		ri, err := fetchRate(ctx, c.API)
+		if err != nil {
+			atomic.AddUint64(&c.refreshErrors, 1)
+			return
+		}
+		c.Cache.Set(c.Key, CacheEntry{Rate: ri, Stored: time.Now()})
+	}()
+}
+
+// copyRate returns a copy of ri, so that callers changing a rate they were
+// given can't change the cached one.
+func copyRate(ri *RateInfo) *RateInfo {
+	cp := *ri
+	cp.Conversions = append([]Conversion(nil), ri.Conversions...)
+	return &cp
+}
@@ -0,0 +1,151 @@
+package dashrates
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingAPI returns its call count as the price. If block is set, it
+// signals on started and waits on block first.
+type countingAPI struct {
+	calls   int64
+	started chan struct{}
+	block   chan struct{}
+}
+
+func (a *countingAPI) DisplayName() string {
+	return "Counting"
+}
+
+func (a *countingAPI) FetchRate() (*RateInfo, error) {
+	if a.block != nil {
+		a.started <- struct{}{}
+		<-a.block
+	}
+	n := atomic.AddInt64(&a.calls, 1)
+	return &RateInfo{
+		BaseCurrency:     "DASH",
+		QuoteCurrency:    "USD",
+		LastPriceDecimal: NewDecimal(n, 0),
+		FetchTime:        time.Now(),
+	}, nil
+}
+
+func TestCachedAPI(t *testing.T) {
+	api := &countingAPI{}
+	c := NewCachedAPI(api, NewMemoryCache(), time.Hour)
+	ctx := context.Background()
+
+	cr, err := c.Fetch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cr.Hit || cr.Rate.LastPriceDecimal.String() != "1" {
+		t.Errorf("first fetch: got hit %v, price %s", cr.Hit, cr.Rate.LastPriceDecimal)
+	}
+
+	cr, err = c.Fetch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cr.Hit || cr.Stale || cr.Rate.LastPriceDecimal.String() != "1" {
+		t.Errorf("second fetch: got hit %v, stale %v, price %s", cr.Hit, cr.Stale, cr.Rate.LastPriceDecimal)
+	}
+
+	if got, want := c.Stats(), (CacheStats{Hits: 1, Misses: 1}); got != want {
+		t.Errorf("stats: got %+v, want %+v", got, want)
+	}
+}
+
+func TestCachedAPIStaleWhileRevalidate(t *testing.T) {
+	api := &countingAPI{}
+	cache := NewMemoryCache()
+	c := NewCachedAPI(api, cache, time.Minute)
+	c.StaleWhileRevalidate = time.Hour
+
+	ri, _ := api.FetchRate()
+	cache.Set(c.Key, CacheEntry{Rate: ri, Stored: time.Now().Add(-2 * time.Minute)})
+
+	cr, err := c.Fetch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cr.Hit || !cr.Stale || cr.Age < 2*time.Minute {
+		t.Errorf("got hit %v, stale %v, age %s, want a stale hit", cr.Hit, cr.Stale, cr.Age)
+	}
+
+	// the background refresh replaces the entry
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		e, _ := cache.Get(c.Key)
+		if e.Rate.LastPriceDecimal.String() == "2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("cache not refreshed in the background")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCachedAPIWaitRespectsContext(t *testing.T) {
+	api := &countingAPI{started: make(chan struct{}), block: make(chan struct{})}
+	defer close(api.block)
+	c := NewCachedAPI(api, NewMemoryCache(), time.Hour)
+
+	// the first caller hangs on the API
+	go c.Fetch(context.Background())
+	<-api.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := c.Fetch(ctx)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want context.DeadlineExceeded", err)
+	}
+	if waited := time.Since(start); waited > time.Second {
+		t.Errorf("waited %s for a hung fetch, past the caller's deadline", waited)
+	}
+}
+
+func TestCachedAPIWrappersShareCache(t *testing.T) {
+	api := &countingAPI{}
+	ecb := NewECBAPI(WithHTTPClient(fakeDoer{body: readECBSample(t)}))
+	cache := NewMemoryCache()
+	usd := NewCachedAPI(NewFXConverter(api, ecb, USD), cache, time.Hour)
+	eur := NewCachedAPI(NewFXConverter(api, ecb, EUR), cache, time.Hour)
+	if usd.Key == eur.Key {
+		t.Fatalf("both converters have the cache key %q", usd.Key)
+	}
+
+	ctx := context.Background()
+	if _, err := usd.Fetch(ctx); err != nil {
+		t.Fatal(err)
+	}
+	cr, err := eur.Fetch(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cr.Hit || cr.Rate.QuoteCurrency != "EUR" {
+		t.Errorf("EUR converter: got hit %v, quote %s, want a fresh EUR rate", cr.Hit, cr.Rate.QuoteCurrency)
+	}
+
+	keys := map[string]bool{}
+	for _, api := range []RateAPI{
+		api,
+		NewCrossRateAPI(NewPair("DASH", "EUR"), api, NewFXRateAPI(ecb, NewPair("USD", "EUR"))),
+		NewCrossRateAPI(NewPair("DASH", "GBP"), api, NewFXRateAPI(ecb, NewPair("USD", "GBP"))),
+		NewAggregator(NewPair("DASH", "USD"), []RateAPI{api}, Median{}),
+		NewAggregator(NewPair("DASH", "USD"), []RateAPI{api, usd}, Median{}),
+	} {
+		key := NewCachedAPI(api, cache, time.Hour).Key
+		if keys[key] {
+			t.Errorf("cache key %q used twice", key)
+		}
+		keys[key] = true
+	}
+}
@@ -0,0 +1,471 @@
+package dashrates
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Candle is the open, high, low and close price of a pair over an interval,
+// built from polled samples.
+type Candle struct {
+	Exchange string
+	Pair     Pair
+
+	// Start is the beginning of the interval, which is aligned to the
+	// interval in UTC, e.g. 1h candles start on the hour.
+	Start    time.Time
+	Interval time.Duration
+
+	// Open and Close are the prices of the first and last samples, fetched
+	// at OpenTime and CloseTime. High and Low are the extremes of the
+	// sampled prices, not of trades, which polling can't see.
+	Open      Decimal
+	High      Decimal
+	Low       Decimal
+	Close     Decimal
+	OpenTime  time.Time
+	CloseTime time.Time
+
+	// Volume is an estimate of the base volume traded within the interval.
+	// Tickers only report volume over a rolling 24 hour window, so it is
+	// worked out from how that changes between samples: the increase, plus
+	// what left the window meanwhile, assumed to have traded at the window's
+	// average rate. It is rounded to 8 decimal places and never negative.
+	//
+	// The estimate is only as good as that assumption. Bursts of trading a
+	// day earlier make it low, exchanges whose window isn't exactly 24 hours
+	// skew it, and an aggregate's volume jumps as sources join or drop out.
+	// Volume between two samples is credited to the candle of the later one,
+	// so after a gap it lands in the first candle that has samples. It is
+	// unset if the samples didn't include volume, and for the first sample
+	// of a series, which has nothing to compare with.
+	Volume Decimal
+
+	// Volume24h is the base volume over the exchange's 24 hour window as of
+	// the last sample. It is unset if the samples didn't include volume.
+	Volume24h Decimal
+
+	// Samples is how many samples the candle was built from. It is zero for
+	// candles added by FillGaps, which carry the previous close.
+	Samples int
+}
+
+// End returns the end of the candle's interval, which is the start of the
+// next one.
+func (c *Candle) End() time.Time {
+	return c.Start.Add(c.Interval)
+}
+
+// merge adds o, which is within the same interval, to c.
+func (c *Candle) merge(o *Candle) {
+	switch {
+	case o.Samples == 0:
+		return
+	case c.Samples == 0:
+		start, interval := c.Start, c.Interval
+		*c = *o
+		c.Start, c.Interval = start, interval
+		return
+	}
+
+	c.Volume = addVolume(c.Volume, o.Volume)
+	if o.OpenTime.Before(c.OpenTime) {
+		c.Open, c.OpenTime = o.Open, o.OpenTime
+	}
+	if !o.CloseTime.Before(c.CloseTime) {
+		c.Close, c.CloseTime = o.Close, o.CloseTime
+		c.Volume24h = o.Volume24h
+	}
+	if o.High.Cmp(c.High) > 0 {
+		c.High = o.High
+	}
+	if o.Low.Cmp(c.Low) < 0 {
+		c.Low = o.Low
+	}
+	c.Samples += o.Samples
+}
+
+// addVolume returns a + b, where either may be unset.
+func addVolume(a, b Decimal) Decimal {
+	switch {
+	case !a.IsSet():
+		return b
+	case !b.IsSet():
+		return a
+	}
+	return a.Add(b)
+}
+
+// volumePlaces is the number of decimal places kept in a candle's Volume.
+const volumePlaces = 8
+
+// volumeSample is a series' 24 hour volume at a point in time, kept by a
+// CandleBuilder to estimate the volume within each candle.
+type volumeSample struct {
+	time      time.Time
+	volume24h Decimal
+
+	// traded is the volume estimated to have traded since the previous
+	// sample, which has been credited to this sample's candle. It is unset
+	// for the first sample.
+	traded Decimal
+}
+
+// estimateTraded estimates the volume traded between two samples of a
+// rolling 24 hour volume, as described for Candle.Volume.
+func estimateTraded(prev, next volumeSample) Decimal {
+	const day = 24 * time.Hour
+	elapsed := next.time.Sub(prev.time)
+	if elapsed > day {
+		elapsed = day
+	}
+
+	// the change in the window, plus its share of prev's total which has
+	// since left it
+	est := new(big.Rat).Sub(next.volume24h.Rat(), prev.volume24h.Rat())
+	left := new(big.Rat).Mul(prev.volume24h.Rat(), big.NewRat(int64(elapsed), int64(day)))
+	est.Add(est, left)
+	if est.Sign() < 0 {
+		return NewDecimal(0, 0)
+	}
+	return roundRat(est, volumePlaces)
+}
+
+// CandleBuilder turns polled samples into candles. It builds candles of a
+// single base interval, such as a minute, and derives longer intervals from
+// them when asked.
+//
+// Samples are placed by their FetchTime, so they can be added late or out of
+// order and still land in the right candle. Intervals with no samples have
+// no candle; FillGaps can fill them in.
+//
+// A CandleBuilder is safe for concurrent use.
+type CandleBuilder struct {
+	interval time.Duration
+
+	mu      sync.Mutex
+	candles map[HistorySeries]map[int64]*Candle
+	volumes map[HistorySeries][]volumeSample // sorted by time
+	cutoff  time.Time
+}
+
+// NewCandleBuilder is a constructor for CandleBuilder. The interval must
+// divide a day evenly, e.g. time.Minute.
+func NewCandleBuilder(interval time.Duration) (*CandleBuilder, error) {
+	if err := checkCandleInterval(interval); err != nil {
+		return nil, err
+	}
+	return &CandleBuilder{
+		interval: interval,
+		candles:  make(map[HistorySeries]map[int64]*Candle),
+		volumes:  make(map[HistorySeries][]volumeSample),
+	}, nil
+}
+
+// Add adds a sample from exchange, or from an aggregate named exchange.
+// Samples without a price are ignored, as are those from before the last
+// Prune.
+func (b *CandleBuilder) Add(exchange string, ri *RateInfo) error {
+	if ri == nil || !ri.LastPriceDecimal.IsSet() {
+		return nil
+	}
+	if ri.FetchTime.IsZero() {
+		return fmt.Errorf("%s rate has no FetchTime", exchange)
+	}
+	key := HistorySeries{
+		Exchange: exchange,
+		Pair:     NewPair(ri.BaseCurrency, ri.QuoteCurrency),
+	}
+	start := ri.FetchTime.UTC().Truncate(b.interval)
+	sample := &Candle{
+		Exchange:  key.Exchange,
+		Pair:      key.Pair,
+		Start:     start,
+		Interval:  b.interval,
+		Open:      ri.LastPriceDecimal,
+		High:      ri.LastPriceDecimal,
+		Low:       ri.LastPriceDecimal,
+		Close:     ri.LastPriceDecimal,
+		OpenTime:  ri.FetchTime,
+		CloseTime: ri.FetchTime,
+		Volume24h: ri.BaseAssetVolumeDecimal,
+		Samples:   1,
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if ri.FetchTime.Before(b.cutoff) {
+		return nil
+	}
+	series, ok := b.candles[key]
+	if !ok {
+		series = make(map[int64]*Candle)
+		b.candles[key] = series
+	}
+	if c, ok := series[start.Unix()]; ok {
+		c.merge(sample)
+	} else {
+		series[start.Unix()] = sample
+	}
+	if ri.BaseAssetVolumeDecimal.IsSet() {
+		b.addVolume(key, volumeSample{time: ri.FetchTime, volume24h: ri.BaseAssetVolumeDecimal})
+	}
+	return nil
+}
+
+// addVolume adds a volume sample to a series, crediting the volume estimated
+// to have traded since the previous sample to its candle. A late sample
+// splits the estimate between its neighbours, so the one credited to the
+// next sample is taken back and worked out again. The caller must hold b.mu.
+func (b *CandleBuilder) addVolume(key HistorySeries, vs volumeSample) {
+	samples := b.volumes[key]
+
+	// keep the samples sorted, after any taken at the same time
+	i := sort.Search(len(samples), func(i int) bool {
+		return samples[i].time.After(vs.time)
+	})
+	if i > 0 {
+		vs.traded = estimateTraded(samples[i-1], vs)
+		b.creditVolume(key, vs.time, vs.traded)
+	}
+	if i < len(samples) {
+		next := &samples[i]
+		b.creditVolume(key, next.time, next.traded.Neg())
+		next.traded = estimateTraded(vs, *next)
+		b.creditVolume(key, next.time, next.traded)
+	}
+
+	samples = append(samples, volumeSample{})
+	copy(samples[i+1:], samples[i:])
+	samples[i] = vs
+	b.volumes[key] = samples
+}
+
+// creditVolume adds traded to the Volume of the series' candle containing t,
+// if there is one. The caller must hold b.mu.
+func (b *CandleBuilder) creditVolume(key HistorySeries, t time.Time, traded Decimal) {
+	if !traded.IsSet() {
+		return
+	}
+	if c, ok := b.candles[key][t.UTC().Truncate(b.interval).Unix()]; ok {
+		c.Volume = addVolume(c.Volume, traded)
+	}
+}
+
+// AddResults adds every successfully fetched rate in rs, under each result's
+// ID, or its exchange name if it has none.
+func (b *CandleBuilder) AddResults(rs *ResultSet) error {
+	for _, r := range rs.Results {
+		if r.Err != nil || r.Rate == nil {
+			continue
+		}
+		exchange := r.ID
+		if exchange == "" {
+			exchange = r.Exchange
+		}
+		if err := b.Add(exchange, r.Rate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddAggregate adds the aggregate rate under name, and the rates of the
+// exchanges it was built from under their own names.
+func (b *CandleBuilder) AddAggregate(name string, agg *Aggregate) error {
+	if agg.Results != nil {
+		if err := b.AddResults(agg.Results); err != nil {
+			return err
+		}
+	}
+	return b.Add(name, agg.Rate)
+}
+
+// Candles returns the candles for exchange and pair starting from start up
+// to but not including end, oldest first. A zero end leaves the range open.
+// The interval must be a multiple of the builder's interval, and divide a
+// day evenly or be a whole number of days.
+func (b *CandleBuilder) Candles(exchange string, pair Pair, interval time.Duration, start, end time.Time) ([]Candle, error) {
+	if interval%b.interval != 0 {
+		return nil, fmt.Errorf("candle interval %s isn't a multiple of %s", interval, b.interval)
+	}
+	if err := checkCandleInterval(interval); err != nil {
+		return nil, err
+	}
+
+	// widen the range to whole candles of the requested interval
+	start = start.UTC().Truncate(interval)
+
+	b.mu.Lock()
+	var candles []Candle
+	for _, c := range b.candles[HistorySeries{Exchange: exchange, Pair: pair}] {
+		if c.Start.Before(start) || (!end.IsZero() && !c.Start.Before(end)) {
+			continue
+		}
+		candles = append(candles, *c)
+	}
+	b.mu.Unlock()
+
+	sortCandles(candles)
+	if interval == b.interval {
+		return candles, nil
+	}
+	return Resample(candles, interval)
+}
+
+// Series lists every series the builder has candles for, sorted by exchange
+// and pair.
+func (b *CandleBuilder) Series() []HistorySeries {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	all := make([]HistorySeries, 0, len(b.candles))
+	for key := range b.candles {
+		all = append(all, key)
+	}
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Exchange != all[j].Exchange {
+			return all[i].Exchange < all[j].Exchange
+		}
+		return all[i].Pair.String() < all[j].Pair.String()
+	})
+	return all
+}
+
+// Prune forgets candles which start before cutoff, returning how many were
+// removed, and ignores samples from before it from then on.
+func (b *CandleBuilder) Prune(cutoff time.Time) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if cutoff.After(b.cutoff) {
+		b.cutoff = cutoff
+	}
+	removed := 0
+	for key, series := range b.candles {
+		for start, c := range series {
+			if c.Start.Before(cutoff) {
+				delete(series, start)
+				removed++
+			}
+		}
+		if len(series) == 0 {
+			delete(b.candles, key)
+		}
+	}
+
+	// keep the last volume sample before the cutoff, which later samples'
+	// estimates start from
+	for key, samples := range b.volumes {
+		i := sort.Search(len(samples), func(i int) bool {
+			return !samples[i].time.Before(cutoff)
+		})
+		if i > 1 {
+			b.volumes[key] = append([]volumeSample(nil), samples[i-1:]...)
+		}
+	}
+	return removed
+}
+
+// Resample combines candles into longer ones, e.g. 1m candles into 5m or 1h
+// candles. The candles must be from one series and all have the same
+// interval, which the new interval must be a multiple of. Gap candles added
+// by FillGaps only count if a new candle has nothing else.
+func Resample(candles []Candle, interval time.Duration) ([]Candle, error) {
+	if err := checkCandleInterval(interval); err != nil {
+		return nil, err
+	}
+	if len(candles) == 0 {
+		return nil, nil
+	}
+	from := candles[0].Interval
+	if from <= 0 || interval%from != 0 {
+		return nil, fmt.Errorf("candle interval %s isn't a multiple of %s", interval, from)
+	}
+
+	merged := make(map[int64]*Candle)
+	for i := range candles {
+		c := &candles[i]
+		if c.Interval != from {
+			return nil, fmt.Errorf("can't resample candles of both %s and %s", from, c.Interval)
+		}
+		if c.Exchange != candles[0].Exchange || c.Pair != candles[0].Pair {
+			return nil, fmt.Errorf("can't resample candles from both %s %s and %s %s",
+				candles[0].Exchange, candles[0].Pair, c.Exchange, c.Pair)
+		}
+
+		start := c.Start.UTC().Truncate(interval)
+		m, ok := merged[start.Unix()]
+		if !ok {
+			m = &Candle{}
+			*m = *c
+			m.Start, m.Interval = start, interval
+			merged[start.Unix()] = m
+			continue
+		}
+		m.merge(c)
+	}
+
+	out := make([]Candle, 0, len(merged))
+	for _, c := range merged {
+		out = append(out, *c)
+	}
+	sortCandles(out)
+	return out, nil
+}
+
+// FillGaps returns candles, which must be sorted and from one series, with a
+// candle added for each missing interval between them. Added candles have no
+// samples or Volume, and their prices are all the previous close, as is usual
+// for charts.
+func FillGaps(candles []Candle) []Candle {
+	if len(candles) == 0 {
+		return nil
+	}
+
+	out := make([]Candle, 0, len(candles))
+	for i, c := range candles {
+		if i > 0 {
+			prev := out[len(out)-1]
+			for next := prev.End(); next.Before(c.Start); next = next.Add(c.Interval) {
+				out = append(out, Candle{
+					Exchange:  prev.Exchange,
+					Pair:      prev.Pair,
+					Start:     next,
+					Interval:  c.Interval,
+					Open:      prev.Close,
+					High:      prev.Close,
+					Low:       prev.Close,
+					Close:     prev.Close,
+					OpenTime:  prev.CloseTime,
+					CloseTime: prev.CloseTime,
+					Volume24h: prev.Volume24h,
+				})
+			}
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// checkCandleInterval returns an error if candles can't be aligned to
+// interval, i.e. it doesn't divide a day evenly or isn't a whole number of
+// days.
+func checkCandleInterval(interval time.Duration) error {
+	const day = 24 * time.Hour
+	if interval <= 0 || (day%interval != 0 && interval%day != 0) {
+		return fmt.Errorf("invalid candle interval %s", interval)
+	}
+	return nil
+}
+
+// sortCandles sorts candles by start time.
+func sortCandles(candles []Candle) {
+	sort.Slice(candles, func(i, j int) bool {
+		return candles[i].Start.Before(candles[j].Start)
+	})
+}
@@ -0,0 +1,381 @@
+package dashrates
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// volumeRate is a DASH/USD sample at t with a 24 hour volume.
+func volumeRate(t time.Time, volume24h int64) *RateInfo {
+	return &RateInfo{
+		BaseCurrency:           "DASH",
+		QuoteCurrency:          "USD",
+		LastPriceDecimal:       NewDecimal(100, 0),
+		BaseAssetVolumeDecimal: NewDecimal(volume24h, 0),
+		FetchTime:              t,
+	}
+}
+
+func candleVolumes(t *testing.T, b *CandleBuilder, interval time.Duration) []string {
+	t.Helper()
+	candles, err := b.Candles("test", NewPair("DASH", "USD"), interval, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, c := range candles {
+		out = append(out, c.Volume.String())
+	}
+	return out
+}
+
+func checkVolumes(t *testing.T, what string, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s: got volumes %v, want %v", what, got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("%s: got volumes %v, want %v", what, got, want)
+		}
+	}
+}
+
+func TestCandleVolume(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	// a steady 1440 DASH a day is 1 DASH a minute
+	b, err := NewCandleBuilder(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 120; i++ {
+		if err := b.Add("test", volumeRate(start.Add(time.Duration(i)*time.Minute), 1440)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := []string{""}
+	for i := 1; i < 120; i++ {
+		want = append(want, "1.00000000")
+	}
+	checkVolumes(t, "1m", candleVolumes(t, b, time.Minute), want)
+	// the first sample has nothing to compare with
+	checkVolumes(t, "1h", candleVolumes(t, b, time.Hour), []string{"59.00000000", "60.00000000"})
+}
+
+func TestCandleVolumeLateSample(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	b, err := NewCandleBuilder(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	add := func(minute int, volume24h int64) {
+		t.Helper()
+		if err := b.Add("test", volumeRate(start.Add(time.Duration(minute)*time.Minute), volume24h)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	add(0, 1440)
+	add(2, 1440)
+	checkVolumes(t, "before", candleVolumes(t, b, time.Minute), []string{"", "2.00000000"})
+
+	// the late sample takes its share of the estimate from the next one
+	add(1, 1440)
+	checkVolumes(t, "after", candleVolumes(t, b, time.Minute), []string{"", "1.00000000", "1.00000000"})
+
+	// a drop in the 24 hour volume isn't negative volume
+	add(3, 1000)
+	checkVolumes(t, "drop", candleVolumes(t, b, time.Minute), []string{"", "1.00000000", "1.00000000", "0"})
+}
+
+func TestCandleVolumeAfterPrune(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	b, err := NewCandleBuilder(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := b.Add("test", volumeRate(start.Add(time.Duration(i)*time.Minute), 1440)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := b.Prune(start.Add(3 * time.Minute)); n != 3 {
+		t.Errorf("pruned %d candles, want 3", n)
+	}
+
+	// the estimate still starts from the last sample before the cutoff
+	if err := b.Add("test", volumeRate(start.Add(4*time.Minute), 1440)); err != nil {
+		t.Fatal(err)
+	}
+	checkVolumes(t, "after prune", candleVolumes(t, b, time.Minute), []string{"2.00000000"})
+}
+
+func TestCandleVolumeWithoutVolume(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	b, err := NewCandleBuilder(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		ri := volumeRate(start.Add(time.Duration(i)*time.Minute), 0)
+		ri.BaseAssetVolumeDecimal = Decimal{}
+		if err := b.Add("test", ri); err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkVolumes(t, "no volume", candleVolumes(t, b, time.Minute), []string{"", ""})
+}
+
+// priceRate is a DASH/USD sample at t with no volume.
+func priceRate(t time.Time, price string) *RateInfo {
+	d, _ := ParseDecimal(price)
+	return &RateInfo{
+		BaseCurrency:     "DASH",
+		QuoteCurrency:    "USD",
+		LastPrice:        d.Float64(),
+		LastPriceDecimal: d,
+		FetchTime:        t,
+	}
+}
+
+// ohlc formats a candle's start, prices and sample count.
+func ohlc(c Candle) string {
+	return fmt.Sprintf("%s %s/%s/%s/%s n=%d", c.Start.Format("15:04"), c.Open, c.High, c.Low, c.Close, c.Samples)
+}
+
+func checkCandles(t *testing.T, what string, candles []Candle, want []string) {
+	t.Helper()
+	got := make([]string, len(candles))
+	for i, c := range candles {
+		got[i] = ohlc(c)
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("%s: got\n%s\nwant\n%s", what, strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestCandleBuilderOutOfOrder(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return start.Add(d) }
+	b, err := NewCandleBuilder(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first minute's samples arrive out of order, one of them after the
+	// next minute has started
+	for _, ri := range []*RateInfo{
+		priceRate(at(30*time.Second), "12"),
+		priceRate(at(10*time.Second), "10"),
+		priceRate(at(70*time.Second), "20"),
+		priceRate(at(50*time.Second), "9"),
+		priceRate(at(0), "11"),
+		priceRate(at(40*time.Second), "13"),
+		priceRate(at(65*time.Second), "21"),
+	} {
+		if err := b.Add("test", ri); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// samples without a price are ignored
+	noPrice := priceRate(at(20*time.Second), "1")
+	noPrice.LastPriceDecimal = Decimal{}
+	if err := b.Add("test", noPrice); err != nil {
+		t.Fatal(err)
+	}
+
+	candles, err := b.Candles("test", NewPair("DASH", "USD"), time.Minute, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCandles(t, "1m", candles, []string{
+		"00:00 11/13/9/9 n=5",
+		"00:01 21/21/20/20 n=2",
+	})
+	if len(candles) == 2 && (!candles[0].OpenTime.Equal(start) || !candles[0].CloseTime.Equal(at(50*time.Second))) {
+		t.Errorf("first candle: opened at %s and closed at %s", candles[0].OpenTime, candles[0].CloseTime)
+	}
+
+	candles, err = b.Candles("test", NewPair("DASH", "USD"), time.Minute, at(time.Minute), at(2*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCandles(t, "range", candles, []string{"00:01 21/21/20/20 n=2"})
+}
+
+func TestCandleBuilderResample(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	b, err := NewCandleBuilder(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a sample every 10 minutes for two hours, with a spike in each hour
+	prices := []string{"10", "11", "15", "9", "10", "12", "20", "21", "30", "19", "22", "23"}
+	for i, price := range prices {
+		if err := b.Add("test", priceRate(start.Add(time.Duration(i)*10*time.Minute), price)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dashUSD := NewPair("DASH", "USD")
+	hourly, err := b.Candles("test", dashUSD, time.Hour, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCandles(t, "1h", hourly, []string{
+		"00:00 10/15/9/12 n=6",
+		"01:00 20/30/19/23 n=6",
+	})
+	for _, c := range hourly {
+		if c.Interval != time.Hour {
+			t.Errorf("%s candle has interval %s", c.Start.Format("15:04"), c.Interval)
+		}
+	}
+
+	// the start is widened to a whole hour
+	hourly, err = b.Candles("test", dashUSD, time.Hour, start.Add(90*time.Minute), time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCandles(t, "from 01:30", hourly, []string{"01:00 20/30/19/23 n=6"})
+
+	for _, interval := range []time.Duration{90 * time.Second, 7 * time.Minute} {
+		if _, err := b.Candles("test", dashUSD, interval, time.Time{}, time.Time{}); err == nil {
+			t.Errorf("no error for %s candles", interval)
+		}
+	}
+
+	// resampling directly, including the gap candles FillGaps adds
+	minutely, err := b.Candles("test", dashUSD, time.Minute, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	daily, err := Resample(FillGaps(minutely), 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCandles(t, "1d", daily, []string{"00:00 10/30/9/23 n=12"})
+
+	mixed := append([]Candle{}, minutely...)
+	mixed[1].Interval = 5 * time.Minute
+	if _, err := Resample(mixed, time.Hour); err == nil {
+		t.Error("no error resampling candles of different intervals")
+	}
+}
+
+func TestFillGaps(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	b, err := NewCandleBuilder(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ri := range []*RateInfo{
+		priceRate(start, "10"),
+		priceRate(start.Add(10*time.Second), "11"),
+		priceRate(start.Add(3*time.Minute), "12"),
+		priceRate(start.Add(4*time.Minute), "13"),
+	} {
+		if err := b.Add("test", ri); err != nil {
+			t.Fatal(err)
+		}
+	}
+	candles, err := b.Candles("test", NewPair("DASH", "USD"), time.Minute, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filled := FillGaps(candles)
+	checkCandles(t, "filled", filled, []string{
+		"00:00 10/11/10/11 n=2",
+		"00:01 11/11/11/11 n=0",
+		"00:02 11/11/11/11 n=0",
+		"00:03 12/12/12/12 n=1",
+		"00:04 13/13/13/13 n=1",
+	})
+	if len(filled) == 5 && filled[1].Exchange != "test" {
+		t.Errorf("gap candle is for %q", filled[1].Exchange)
+	}
+	if FillGaps(nil) != nil {
+		t.Error("FillGaps(nil) isn't nil")
+	}
+}
+
+func TestCandleBuilderPrune(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	b, err := NewCandleBuilder(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if err := b.Add("test", priceRate(start.Add(time.Duration(i)*time.Minute), "10")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := b.Prune(start.Add(3 * time.Minute)); n != 3 {
+		t.Errorf("pruned %d candles, want 3", n)
+	}
+	// samples from before the cutoff are ignored from then on
+	if err := b.Add("test", priceRate(start.Add(time.Minute), "1")); err != nil {
+		t.Fatal(err)
+	}
+	candles, err := b.Candles("test", NewPair("DASH", "USD"), time.Minute, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCandles(t, "after pruning", candles, []string{
+		"00:03 10/10/10/10 n=1",
+		"00:04 10/10/10/10 n=1",
+	})
+
+	// an earlier cutoff doesn't bring anything back
+	if n := b.Prune(start); n != 0 {
+		t.Errorf("pruned %d candles, want 0", n)
+	}
+	if n := b.Prune(start.Add(time.Hour)); n != 2 {
+		t.Errorf("pruned %d candles, want 2", n)
+	}
+	if series := b.Series(); len(series) != 0 {
+		t.Errorf("got series %v after pruning everything", series)
+	}
+}
+
+func TestCandleBuilderAddAggregate(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	b, err := NewCandleBuilder(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agg := &Aggregate{
+		Rate: priceRate(start, "11"),
+		Results: &ResultSet{Results: []Result{
+			{ID: "kraken", Exchange: "Kraken", Rate: priceRate(start, "10")},
+			{Exchange: "Other", Rate: priceRate(start, "12")},
+			{ID: "failed", Exchange: "Failed", Err: errors.New("down")},
+		}},
+	}
+	if err := b.AddAggregate("DASH/USD median", agg); err != nil {
+		t.Fatal(err)
+	}
+
+	series := b.Series()
+	var names []string
+	for _, s := range series {
+		names = append(names, s.Exchange+" "+s.Pair.String())
+	}
+	want := "DASH/USD median DASH/USD,Other DASH/USD,kraken DASH/USD"
+	if got := strings.Join(names, ","); got != want {
+		t.Errorf("got series %s, want %s", got, want)
+	}
+
+	candles, err := b.Candles("DASH/USD median", NewPair("DASH", "USD"), time.Minute, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkCandles(t, "aggregate", candles, []string{"00:00 11/11/11/11 n=1"})
+}
@@ -1,9 +1,8 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
+	"fmt"
 	"strconv"
 	"strings"
 	"time"
@@ -14,57 +13,111 @@ import (
 type CexAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("cex", func(opts ...Option) RateAPI { return NewCexAPI(opts...) })
 }
 
 // NewCexAPI is a constructor for CexAPI.
-func NewCexAPI() *CexAPI {
+func NewCexAPI(opts ...Option) *CexAPI {
+	a, _ := NewCexAPIForPair("DASH", "USD", opts...)
+	return a
+}
+
+// NewCexAPIForPair is a constructor for a CexAPI which fetches the rate for the
+// base/quote pair, e.g. ("DASH", "USD"). It returns an error wrapping
+// ErrPairNotFound for pairs not in cexPairs.
+func NewCexAPIForPair(base, quote string, opts ...Option) (*CexAPI, error) {
+	base, quote, err := checkPair("CEX.IO", cexPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &CexAPI{
 		BaseAPIURL:          "https://cex.io",
-		PriceTickerEndpoint: "/api/ticker/DASH/USD",
-	}
+		PriceTickerEndpoint: "/api/ticker/" + exchangeSymbol("cex", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// cexPairs lists the CEX.IO markets which NewCexAPIForPair accepts.
+var cexPairs = []string{"DASH/USD", "DASH/EUR", "DASH/BTC"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *CexAPI) DisplayName() string {
 	return "CEX.IO"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *CexAPI) Describe() Description {
+	return Description{
+		ID:        "cex",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(cexPairs),
+		Price:     LastTradePrice,
+		HasVolume: true,
+		URL:       "https://cex.io",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Cex API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *CexAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the Cex API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *CexAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res cexPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	data, err := res.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
-	// parse json and extract Dash rate
-	var res cexPubTickerResp
-	err = json.Unmarshal(body, &res)
+	last, err := ParseDecimal(res.Last)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
-
-	data, err := res.Normalize()
+	volume, err := ParseDecimal(res.Volume)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    data.Pair.Base,
-		QuoteCurrency:   data.Pair.Quote,
-		LastPrice:       data.Last,
-		BaseAssetVolume: data.Volume,
-		FetchTime:       now,
+		BaseCurrency:           data.Pair.Base,
+		QuoteCurrency:          data.Pair.Quote,
+		LastPrice:              data.Last,
+		LastPriceDecimal:       last,
+		BaseAssetVolume:        data.Volume,
+		BaseAssetVolumeDecimal: volume,
+		Bid:                    res.Bid,
+		Ask:                    res.Ask,
+		High:                   optionalDecimal(res.High),
+		Low:                    optionalDecimal(res.Low),
+		FetchTime:              now,
+		ServerTime:             data.Timestamp,
 	}
 
 	return &ri, nil
@@ -78,8 +131,8 @@ type cexPubTickerResp struct {
 	Last                  string  `json:"last"`
 	Volume                string  `json:"volume"`
 	Volume30d             string  `json:"volume30d"`
-	Bid                   float64 `json:"bid"`
-	Ask                   float64 `json:"ask"`
+	Bid                   Decimal `json:"bid"`
+	Ask                   Decimal `json:"ask"`
 	PriceChange           string  `json:"priceChange"`
 	PriceChangePercentage string  `json:"priceChangePercentage"`
 	Pair                  string  `json:"pair"`
@@ -149,6 +202,9 @@ func (resp *cexPubTickerResp) Normalize() (*cexPubTickerData, error) {
 	}
 
 	pair := strings.Split(resp.Pair, ":")
+	if len(pair) != 2 {
+		return nil, fmt.Errorf("invalid pair %q", resp.Pair)
+	}
 
 	return &cexPubTickerData{
 		Timestamp:             time.Unix(tsEpoch, 0),
@@ -157,8 +213,8 @@ func (resp *cexPubTickerResp) Normalize() (*cexPubTickerData, error) {
 		Last:                  last,
 		Volume:                volume,
 		Volume30d:             volume30d,
-		Bid:                   resp.Bid,
-		Ask:                   resp.Ask,
+		Bid:                   resp.Bid.Float64(),
+		Ask:                   resp.Ask.Float64(),
 		PriceChange:           priceChange,
 		PriceChangePercentage: priceChangePercentage,
 		Pair:                  cexPair{Base: pair[0], Quote: pair[1]},
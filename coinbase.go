@@ -1,11 +1,8 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
-	"strconv"
+	"context"
+	"strings"
 	"time"
 )
 
@@ -14,14 +11,36 @@ import (
 type CoinbaseAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("coinbase", func(opts ...Option) RateAPI { return NewCoinbaseAPI(opts...) })
 }
 
 // NewCoinbaseAPI is a constructor for CoinbaseAPI.
-func NewCoinbaseAPI() *CoinbaseAPI {
+func NewCoinbaseAPI(opts ...Option) *CoinbaseAPI {
+	a, _ := NewCoinbaseAPIForPair("DASH", "USD", opts...)
+	return a
+}
+
+// NewCoinbaseAPIForPair is a constructor for a CoinbaseAPI which fetches the
+// rate for the base/quote pair, e.g. ("DASH", "EUR"). Coinbase quotes each
+// currency against every other one it knows, so no pair is rejected up front;
+// an unknown pair gives an error wrapping ErrPairNotFound when fetched.
+func NewCoinbaseAPIForPair(base, quote string, opts ...Option) (*CoinbaseAPI, error) {
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+
+	o := newOptions(opts)
 	return &CoinbaseAPI{
 		BaseAPIURL:          "https://api.coinbase.com",
-		PriceTickerEndpoint: "/v2/exchange-rates?currency=DASH",
-	}
+		PriceTickerEndpoint: "/v2/exchange-rates?currency=" + base,
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
 // DisplayName returns the exchange display name. It is part of the RateAPI
@@ -30,50 +49,56 @@ func (a *CoinbaseAPI) DisplayName() string {
 	return "Coinbase"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *CoinbaseAPI) Describe() Description {
+	return Description{
+		ID:    "coinbase",
+		Name:  a.DisplayName(),
+		Pair:  NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Price: IndicativePrice,
+		URL:   "https://www.coinbase.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Coinbase API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *CoinbaseAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	now := time.Now()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+	return a.FetchRateContext(context.Background())
+}
 
+// FetchRateContext gets the Dash exchange rate from the Coinbase API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *CoinbaseAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
 	// parse json and extract Dash rate
 	var res coinbaseExchangeRatesResp
-	err = json.Unmarshal(body, &res)
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
 
-	rate, ok := res.Data.Rates["USD"]
+	now := time.Now()
+
+	rate, ok := res.Data.Rates[a.QuoteCurrency]
 	if !ok {
-		err = fmt.Errorf("oh no, %s does not have %s/USD pair",
-			a.DisplayName(),
-			res.Data.Currency,
-		)
-		return nil, err
+		return nil, pairNotFound(a.DisplayName(), a.BaseCurrency, a.QuoteCurrency)
 	}
 
-	price, err := strconv.ParseFloat(rate, 64)
+	price, err := ParseDecimal(rate)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    res.Data.Currency,
-		QuoteCurrency:   "USD",
-		LastPrice:       price,
-		BaseAssetVolume: 0,
-		FetchTime:       now,
+		BaseCurrency:     a.BaseCurrency,
+		QuoteCurrency:    a.QuoteCurrency,
+		LastPrice:        price.Float64(),
+		LastPriceDecimal: price,
+		BaseAssetVolume:  0,
+		FetchTime:        now,
 	}
 
 	return &ri, nil
@@ -1,9 +1,7 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
 	"strconv"
 	"time"
 )
@@ -13,57 +11,111 @@ import (
 type CoinbaseProAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("coinbasepro", func(opts ...Option) RateAPI { return NewCoinbaseProAPI(opts...) })
 }
 
 // NewCoinbaseProAPI is a constructor for CoinbaseProAPI.
-func NewCoinbaseProAPI() *CoinbaseProAPI {
+func NewCoinbaseProAPI(opts ...Option) *CoinbaseProAPI {
+	a, _ := NewCoinbaseProAPIForPair("DASH", "USD", opts...)
+	return a
+}
+
+// NewCoinbaseProAPIForPair is a constructor for a CoinbaseProAPI which fetches
+// the rate for the base/quote pair, e.g. ("DASH", "USD"). It returns an error
+// wrapping ErrPairNotFound for pairs not in coinbaseProPairs.
+func NewCoinbaseProAPIForPair(base, quote string, opts ...Option) (*CoinbaseProAPI, error) {
+	base, quote, err := checkPair("Coinbase Pro", coinbaseProPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &CoinbaseProAPI{
 		BaseAPIURL:          "https://api.pro.coinbase.com",
-		PriceTickerEndpoint: "/products/DASH-USD/ticker",
-	}
+		PriceTickerEndpoint: "/products/" + exchangeSymbol("coinbasepro", base, quote) + "/ticker",
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// coinbaseProPairs lists the Coinbase Pro markets which
+// NewCoinbaseProAPIForPair accepts.
+var coinbaseProPairs = []string{"DASH/USD", "DASH/BTC", "BTC/USD"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *CoinbaseProAPI) DisplayName() string {
 	return "Coinbase Pro"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *CoinbaseProAPI) Describe() Description {
+	return Description{
+		ID:         "coinbasepro",
+		Name:       a.DisplayName(),
+		Pair:       NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:      parsePairs(coinbaseProPairs),
+		Price:      LastTradePrice,
+		HasVolume:  true,
+		URL:        "https://pro.coinbase.com",
+		Deprecated: "Coinbase Pro has been replaced by Coinbase Advanced Trade.",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the CoinbasePro API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *CoinbaseProAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the CoinbasePro API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *CoinbaseProAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res coinbaseProTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	data, err := res.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
-	// parse json and extract Dash rate
-	var res coinbaseProTickerResp
-	err = json.Unmarshal(body, &res)
+	last, err := ParseDecimal(res.Price)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
-
-	data, err := res.Normalize()
+	volume, err := ParseDecimal(res.Volume)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "USD",
-		LastPrice:       data.Price,
-		BaseAssetVolume: data.Volume,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              data.Price,
+		LastPriceDecimal:       last,
+		BaseAssetVolume:        data.Volume,
+		BaseAssetVolumeDecimal: volume,
+		Bid:                    optionalDecimal(res.Bid),
+		Ask:                    optionalDecimal(res.Ask),
+		FetchTime:              now,
+		ServerTime:             data.Timestamp,
 	}
 
 	return &ri, nil
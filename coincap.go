@@ -1,10 +1,7 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
-	"strconv"
+	"context"
 	"time"
 )
 
@@ -13,56 +10,95 @@ import (
 type CoinCapAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("coincap", func(opts ...Option) RateAPI { return NewCoinCapAPI(opts...) })
 }
 
 // NewCoinCapAPI is a constructor for CoinCapAPI.
-func NewCoinCapAPI() *CoinCapAPI {
+func NewCoinCapAPI(opts ...Option) *CoinCapAPI {
+	a, _ := NewCoinCapAPIForPair("BTC", "USD", opts...)
+	return a
+}
+
+// NewCoinCapAPIForPair is a constructor for a CoinCapAPI which fetches the rate
+// for the base/quote pair, e.g. ("BTC", "USD"). It returns an error wrapping
+// ErrPairNotFound for pairs not in coinCapPairs.
+func NewCoinCapAPIForPair(base, quote string, opts ...Option) (*CoinCapAPI, error) {
+	base, quote, err := checkPair("CoinCap", coinCapPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &CoinCapAPI{
 		BaseAPIURL:          "https://api.coincap.io",
-		PriceTickerEndpoint: "/v2/rates/bitcoin",
-	}
+		PriceTickerEndpoint: "/v2/rates/" + exchangeSymbol("coincap", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// coinCapPairs lists the CoinCap markets which NewCoinCapAPIForPair accepts.
+var coinCapPairs = []string{"BTC/USD", "DASH/USD"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *CoinCapAPI) DisplayName() string {
 	return "CoinCap"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *CoinCapAPI) Describe() Description {
+	return Description{
+		ID:    "coincap",
+		Name:  a.DisplayName(),
+		Pair:  NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs: parsePairs(coinCapPairs),
+		Price: IndicativePrice,
+		URL:   "https://coincap.io",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the CoinCap API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *CoinCapAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	now := time.Now()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+	return a.FetchRateContext(context.Background())
+}
 
+// FetchRateContext gets the Dash exchange rate from the CoinCap API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *CoinCapAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
 	// parse json and extract Dash rate
 	var res coinCapPubTickerResp
-	err = json.Unmarshal(body, &res)
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
+
+	now := time.Now()
 	rateUSD, err := res.GetRateUSD()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "BTC",
-		QuoteCurrency:   "USD",
-		LastPrice:       rateUSD,
-		BaseAssetVolume: 0,
-		FetchTime:       now,
+		BaseCurrency:     a.BaseCurrency,
+		QuoteCurrency:    a.QuoteCurrency,
+		LastPrice:        rateUSD.Float64(),
+		LastPriceDecimal: rateUSD,
+		BaseAssetVolume:  0,
+		FetchTime:        now,
+		ServerTime:       unixMilliTime(res.Timestamp),
 	}
 
 	return &ri, nil
@@ -82,10 +118,6 @@ type coinCapPubTickerResp struct {
 
 // GetRateUSD returns the USD Rate without bothering to re-write an entire
 // struct with mostly strings.
-func (resp *coinCapPubTickerResp) GetRateUSD() (float64, error) {
-	rate, err := strconv.ParseFloat(resp.Data.RateUSD, 64)
-	if err != nil {
-		return 0, err
-	}
-	return rate, nil
+func (resp *coinCapPubTickerResp) GetRateUSD() (Decimal, error) {
+	return ParseDecimal(resp.Data.RateUSD)
 }
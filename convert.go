@@ -0,0 +1,134 @@
+package dashrates
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Conversion records one step a RateInfo went through to reach its quote
+// currency, e.g. a DASH/USDT price converted to USD at a USDT/USD rate from
+// Kraken. The fields describe the rate used for the step.
+//
+// Inverted means the rate was used upside down, e.g. a BTC/USD rate used to
+// get from USD to BTC.
+type Conversion struct {
+	Source        string
+	BaseCurrency  string
+	QuoteCurrency string
+	Rate          Decimal
+	Inverted      bool `json:",omitempty"`
+	FetchTime     time.Time
+	ServerTime    time.Time
+}
+
+// convertQuote returns a copy of ri with its prices converted into the quote
+// currency of leg, whose base currency must be ri's quote currency. Volume in
+// the base currency is unchanged, and the step is appended to Conversions.
+func convertQuote(ri *RateInfo, leg *RateInfo, source string) (*RateInfo, error) {
+	if leg.BaseCurrency != ri.QuoteCurrency {
+		return nil, fmt.Errorf("can't convert %s/%s with a %s/%s rate",
+			ri.BaseCurrency, ri.QuoteCurrency, leg.BaseCurrency, leg.QuoteCurrency)
+	}
+	rate := leg.LastPriceDecimal
+	if rate.Sign() <= 0 {
+		return nil, badResponse(fmt.Errorf("%s: invalid %s/%s rate %q",
+			source, leg.BaseCurrency, leg.QuoteCurrency, rate))
+	}
+
+	out := *ri
+	out.QuoteCurrency = leg.QuoteCurrency
+	out.LastPriceDecimal = ri.LastPriceDecimal.Mul(rate)
+	out.LastPrice = out.LastPriceDecimal.Float64()
+	out.Bid = ri.Bid.Mul(rate)
+	out.Ask = ri.Ask.Mul(rate)
+	out.High = ri.High.Mul(rate)
+	out.Low = ri.Low.Mul(rate)
+	out.Open = ri.Open.Mul(rate)
+	out.QuoteAssetVolume = ri.QuoteAssetVolume.Mul(rate)
+
+	out.Conversions = make([]Conversion, 0, len(ri.Conversions)+len(leg.Conversions)+1)
+	out.Conversions = append(out.Conversions, ri.Conversions...)
+	out.Conversions = append(out.Conversions, leg.Conversions...)
+	out.Conversions = append(out.Conversions, Conversion{
+		Source:        source,
+		BaseCurrency:  leg.BaseCurrency,
+		QuoteCurrency: leg.QuoteCurrency,
+		Rate:          rate,
+		FetchTime:     leg.FetchTime,
+		ServerTime:    leg.ServerTime,
+	})
+
+	return &out, nil
+}
+
+// StablecoinConverter wraps a RateAPI and converts rates quoted in a
+// stablecoin into the currency the stablecoin is pegged to, using the market
+// rate for the stablecoin rather than assuming the peg holds. Rates quoted in
+// anything else are passed through untouched.
+//
+// Sources gives the RateAPI used to price each stablecoin, e.g. a USDT/USD
+// market. A rate quoted in a stablecoin without a source is an error, rather
+// than being passed off as a price in the pegged currency.
+type StablecoinConverter struct {
+	API     RateAPI
+	Sources map[Currency]RateAPI
+}
+
+// NewStablecoinConverter is a constructor for StablecoinConverter. For
+// example, to report Bitbns's DASH/USDT price in USD using Kraken's USDT/USD
+// price:
+//
+//	usdt, _ := NewKrakenAPIForPair("USDT", "USD")
+//	api := NewStablecoinConverter(NewBitbnsAPI(), map[Currency]RateAPI{USDT: usdt})
+func NewStablecoinConverter(api RateAPI, sources map[Currency]RateAPI) *StablecoinConverter {
+	return &StablecoinConverter{
+		API:     api,
+		Sources: sources,
+	}
+}
+
+// DisplayName returns the display name of the wrapped API. It is part of the
+// RateAPI interface implementation.
+func (c *StablecoinConverter) DisplayName() string {
+	return c.API.DisplayName()
+}
+
+// FetchRate gets the rate from the wrapped API, converting it if needed.
+//
+// This is part of the RateAPI interface implementation.
+func (c *StablecoinConverter) FetchRate() (*RateInfo, error) {
+	return c.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the rate from the wrapped API, converting it if
+// needed, and giving up when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (c *StablecoinConverter) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	ri, err := fetchRate(ctx, c.API)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := Currency(ri.QuoteCurrency)
+	if !quote.IsStablecoin() {
+		return ri, nil
+	}
+	src, ok := c.Sources[quote]
+	if !ok {
+		return nil, fmt.Errorf("%s: no source configured for %s/%s",
+			c.DisplayName(), quote, quote.Peg())
+	}
+
+	leg, err := fetchRate(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	if leg.QuoteCurrency != string(quote.Peg()) {
+		return nil, fmt.Errorf("%s: %s source gave a %s/%s rate, want %s/%s",
+			c.DisplayName(), quote, leg.BaseCurrency, leg.QuoteCurrency, quote, quote.Peg())
+	}
+
+	return convertQuote(ri, leg, src.DisplayName())
+}
@@ -1,9 +1,8 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
+	"errors"
 	"time"
 )
 
@@ -12,52 +11,106 @@ import (
 type Crex24API struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("crex24", func(opts ...Option) RateAPI { return NewCrex24API(opts...) })
 }
 
 // NewCrex24API is a constructor for Crex24API.
-func NewCrex24API() *Crex24API {
+func NewCrex24API(opts ...Option) *Crex24API {
+	a, _ := NewCrex24APIForPair("DASH", "BTC", opts...)
+	return a
+}
+
+// NewCrex24APIForPair is a constructor for a Crex24API which fetches the rate
+// for the base/quote pair, e.g. ("DASH", "BTC"). It returns an error wrapping
+// ErrPairNotFound for pairs not in crex24Pairs.
+func NewCrex24APIForPair(base, quote string, opts ...Option) (*Crex24API, error) {
+	base, quote, err := checkPair("CREX24", crex24Pairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &Crex24API{
 		BaseAPIURL:          "https://api.crex24.com/v2/public",
-		PriceTickerEndpoint: "/tickers?instrument=DASH-BTC",
-	}
+		PriceTickerEndpoint: "/tickers?instrument=" + exchangeSymbol("crex24", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// crex24Pairs lists the CREX24 markets which NewCrex24APIForPair accepts.
+var crex24Pairs = []string{"DASH/BTC"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *Crex24API) DisplayName() string {
 	return "CREX24"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *Crex24API) Describe() Description {
+	return Description{
+		ID:        "crex24",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(crex24Pairs),
+		Price:     LastTradePrice,
+		HasVolume: true,
+		URL:       "https://crex24.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Crex24 API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *Crex24API) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the Crex24 API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *Crex24API) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res crex24PubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if len(res) == 0 {
+		return nil, pairNotFound(a.DisplayName(), a.BaseCurrency, a.QuoteCurrency)
 	}
 
-	// parse json and extract Dash rate
-	var res crex24PubTickerResp
-	err = json.Unmarshal(body, &res)
-	if err != nil {
-		return nil, err
+	if !res[0].Last.IsSet() {
+		return nil, badResponse(errors.New("no price in response"))
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "BTC",
-		LastPrice:       res[0].Last,
-		BaseAssetVolume: res[0].BaseVolume,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              res[0].Last.Float64(),
+		LastPriceDecimal:       res[0].Last,
+		BaseAssetVolume:        res[0].BaseVolume.Float64(),
+		BaseAssetVolumeDecimal: res[0].BaseVolume,
+		Bid:                    res[0].Bid,
+		Ask:                    res[0].Ask,
+		High:                   res[0].High,
+		Low:                    res[0].Low,
+		QuoteAssetVolume:       res[0].QuoteVolume,
+		FetchTime:              now,
+		ServerTime:             res[0].Timestamp,
 	}
 
 	return &ri, nil
@@ -69,15 +122,15 @@ type crex24PubTickerResp []crex24PubTickerData
 // crex24PubTickerData is used in parsing the Crex24 API Dataonse only.
 type crex24PubTickerData struct {
 	Instrument    string    `json:"instrument"`
-	Last          float64   `json:"last"`
+	Last          Decimal   `json:"last"`
 	PercentChange float64   `json:"PercentChange"`
-	Low           float64   `json:"low"`
-	High          float64   `json:"high"`
-	BaseVolume    float64   `json:"baseVolume"`
-	QuoteVolume   float64   `json:"quoteVolume"`
+	Low           Decimal   `json:"low"`
+	High          Decimal   `json:"high"`
+	BaseVolume    Decimal   `json:"baseVolume"`
+	QuoteVolume   Decimal   `json:"quoteVolume"`
 	VolumeInBtc   float64   `json:"volumeInBtc"`
 	VolumeInUsd   float64   `json:"volumeInUsd"`
-	Ask           float64   `json:"ask"`
-	Bid           float64   `json:"bid"`
+	Ask           Decimal   `json:"ask"`
+	Bid           Decimal   `json:"bid"`
 	Timestamp     time.Time `json:"timestamp"`
 }
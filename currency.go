@@ -0,0 +1,309 @@
+package dashrates
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Currency is a canonical, upper-case currency code, e.g. "DASH", "BTC" or
+// "USD". Exchanges' own spellings, such as Bitfinex's "dsh" or Indodax's
+// "drk", are translated to and from these by a SymbolFormat.
+type Currency string
+
+// Currencies used by the adapters.
+const (
+	DASH Currency = "DASH"
+	BTC  Currency = "BTC"
+	ETH  Currency = "ETH"
+	BNB  Currency = "BNB"
+	USDT Currency = "USDT"
+	USDC Currency = "USDC"
+	BUSD Currency = "BUSD"
+	DAI  Currency = "DAI"
+	TUSD Currency = "TUSD"
+	USD  Currency = "USD"
+	EUR  Currency = "EUR"
+	GBP  Currency = "GBP"
+	RUB  Currency = "RUB"
+	IDR  Currency = "IDR"
+	INR  Currency = "INR"
+)
+
+// knownCurrencies is used to find where one currency ends and the next
+// begins in symbols without a separator, such as "DASHUSDT".
+var knownCurrencies = map[Currency]bool{
+	DASH: true, BTC: true, ETH: true, BNB: true,
+	USDT: true, USDC: true, BUSD: true, DAI: true, TUSD: true,
+	USD: true, EUR: true, GBP: true, RUB: true, IDR: true, INR: true,
+}
+
+// stablecoins maps each stablecoin the package knows about to the currency
+// it is pegged to.
+var stablecoins = map[Currency]Currency{
+	USDT: USD,
+	USDC: USD,
+	BUSD: USD,
+	DAI:  USD,
+	TUSD: USD,
+}
+
+// IsStablecoin reports whether c is a stablecoin, such as USDT. Stablecoins
+// are distinct currencies from the fiat they track, and their price can and
+// does drift from the peg.
+func (c Currency) IsStablecoin() bool {
+	_, ok := stablecoins[c]
+	return ok
+}
+
+// Peg returns the currency a stablecoin is pegged to, e.g. USD for USDT, or
+// "" if c isn't a stablecoin.
+func (c Currency) Peg() Currency {
+	return stablecoins[c]
+}
+
+// Pair is a market pricing the Base currency in the Quote currency, e.g.
+// DASH/USD is the price of one DASH in USD.
+type Pair struct {
+	Base  Currency
+	Quote Currency
+}
+
+// NewPair returns the pair base/quote, upper-casing both codes.
+func NewPair(base, quote string) Pair {
+	return Pair{
+		Base:  Currency(strings.ToUpper(base)),
+		Quote: Currency(strings.ToUpper(quote)),
+	}
+}
+
+// ParsePair parses a pair in the canonical "BASE/QUOTE" form, e.g.
+// "DASH/BTC". Use ParseSymbol for exchanges' own formats.
+func ParsePair(s string) (Pair, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return Pair{}, fmt.Errorf("invalid pair %q", s)
+	}
+	return NewPair(parts[0], parts[1]), nil
+}
+
+// String formats p as "BASE/QUOTE".
+func (p Pair) String() string {
+	return string(p.Base) + "/" + string(p.Quote)
+}
+
+// Inverse returns the pair with base and quote swapped, e.g. BTC/DASH for
+// DASH/BTC.
+func (p Pair) Inverse() Pair {
+	return Pair{Base: p.Quote, Quote: p.Base}
+}
+
+// SymbolFormat describes how an exchange writes currencies and pairs in its
+// API.
+type SymbolFormat struct {
+	// Separator goes between the two currencies, e.g. "_" for "DASH_BTC".
+	// It may be empty, as in "DASHBTC".
+	Separator string
+
+	// Lower means the exchange writes symbols in lower case.
+	Lower bool
+
+	// QuoteFirst means the exchange puts the quote currency first, e.g.
+	// "BTC-DASH" for DASH/BTC.
+	QuoteFirst bool
+
+	// OmitQuote, if set, is a quote currency the exchange leaves out of
+	// symbols, e.g. Bitbns writes DASH/INR as just "DASH".
+	OmitQuote Currency
+
+	// Aliases maps canonical currencies to the exchange's own codes where
+	// they differ, e.g. DASH to "DSH" on Bitfinex.
+	Aliases map[Currency]string
+}
+
+// Currency returns the exchange's code for c.
+func (f SymbolFormat) Currency(c Currency) string {
+	code, ok := f.Aliases[c]
+	if !ok {
+		code = string(c)
+	}
+	if f.Lower {
+		return strings.ToLower(code)
+	}
+	return strings.ToUpper(code)
+}
+
+// ParseCurrency returns the canonical currency for the exchange's code.
+func (f SymbolFormat) ParseCurrency(code string) Currency {
+	for c, alias := range f.Aliases {
+		if strings.EqualFold(alias, code) {
+			return c
+		}
+	}
+	return Currency(strings.ToUpper(code))
+}
+
+// Symbol returns the exchange's symbol for p.
+func (f SymbolFormat) Symbol(p Pair) string {
+	base, quote := f.Currency(p.Base), f.Currency(p.Quote)
+	switch {
+	case f.OmitQuote != "" && p.Quote == f.OmitQuote:
+		return base
+	case f.QuoteFirst:
+		return quote + f.Separator + base
+	}
+	return base + f.Separator + quote
+}
+
+// ParseSymbol parses one of the exchange's symbols into a Pair. Symbols
+// without a separator are split where both halves are currencies the package
+// knows about, or failing that where one half is.
+func (f SymbolFormat) ParseSymbol(s string) (Pair, error) {
+	if f.Separator != "" {
+		parts := strings.Split(s, f.Separator)
+		if len(parts) == 1 && f.OmitQuote != "" && s != "" {
+			return Pair{Base: f.ParseCurrency(s), Quote: f.OmitQuote}, nil
+		}
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return Pair{}, fmt.Errorf("invalid symbol %q", s)
+		}
+		return f.pair(parts[0], parts[1]), nil
+	}
+
+	// try every split, best first
+	known := func(code string) bool {
+		return knownCurrencies[f.ParseCurrency(code)]
+	}
+	for i := 1; i < len(s); i++ {
+		if known(s[:i]) && known(s[i:]) {
+			return f.pair(s[:i], s[i:]), nil
+		}
+	}
+	if f.OmitQuote != "" && s != "" {
+		return Pair{Base: f.ParseCurrency(s), Quote: f.OmitQuote}, nil
+	}
+	for i := 1; i < len(s); i++ {
+		if known(s[:i]) || known(s[i:]) {
+			return f.pair(s[:i], s[i:]), nil
+		}
+	}
+
+	return Pair{}, fmt.Errorf("can't split symbol %q into currencies", s)
+}
+
+// pair builds a Pair from the two codes in a symbol, in the order written.
+func (f SymbolFormat) pair(first, second string) Pair {
+	if f.QuoteFirst {
+		first, second = second, first
+	}
+	return Pair{Base: f.ParseCurrency(first), Quote: f.ParseCurrency(second)}
+}
+
+// symbolFormats holds each exchange's SymbolFormat, keyed by exchange ID.
+var (
+	symbolFormatsMu sync.RWMutex
+	symbolFormats   = map[string]SymbolFormat{
+		"bibox":        {Separator: "_"},
+		"bigone":       {Separator: "-"},
+		"binance":      {},
+		"bitbns":       {OmitQuote: INR},
+		"bitfinex":     {Lower: true, Aliases: map[Currency]string{DASH: "DSH"}},
+		"bittrex":      {Separator: "-", QuoteFirst: true},
+		"bvnex":        {Separator: "_", Lower: true},
+		"cex":          {Separator: "/"},
+		"coinbase":     {},
+		"coinbasepro":  {Separator: "-"},
+		"coincap":      {Lower: true, OmitQuote: USD, Aliases: map[Currency]string{BTC: "bitcoin", DASH: "dash"}},
+		"crex24":       {Separator: "-"},
+		"digifinex":    {Separator: "_", Lower: true},
+		"exmo":         {Separator: "_"},
+		"hitbtc":       {},
+		"huobi":        {Lower: true},
+		"indodax":      {Separator: "_", Lower: true, Aliases: map[Currency]string{DASH: "DRK"}},
+		"kraken":       {Aliases: map[Currency]string{BTC: "XBT"}},
+		"kucoin":       {Separator: "-"},
+		"liquid":       {},
+		"okex":         {Separator: "-"},
+		"poloniex":     {Separator: "_", QuoteFirst: true},
+		"southxchange": {Separator: "/"},
+		"triv":         {},
+		"uphold":       {},
+		"whitebit":     {Separator: "_"},
+		"yobit":        {Separator: "_", Lower: true},
+	}
+)
+
+// RegisterSymbolFormat sets the SymbolFormat for an exchange, replacing any
+// existing one. The exchange is identified by its lower-case ID, e.g.
+// "kraken".
+func RegisterSymbolFormat(exchange string, f SymbolFormat) {
+	symbolFormatsMu.Lock()
+	defer symbolFormatsMu.Unlock()
+	symbolFormats[exchange] = f
+}
+
+// LookupSymbolFormat returns the SymbolFormat registered for an exchange.
+func LookupSymbolFormat(exchange string) (SymbolFormat, bool) {
+	symbolFormatsMu.RLock()
+	defer symbolFormatsMu.RUnlock()
+	f, ok := symbolFormats[exchange]
+	return f, ok
+}
+
+// SymbolExchanges returns the IDs of the exchanges with a registered
+// SymbolFormat, sorted.
+func SymbolExchanges() []string {
+	symbolFormatsMu.RLock()
+	defer symbolFormatsMu.RUnlock()
+	ids := make([]string, 0, len(symbolFormats))
+	for id := range symbolFormats {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// FormatSymbol returns the exchange's symbol for p, e.g. "dshusd" for
+// DASH/USD on Bitfinex.
+func FormatSymbol(exchange string, p Pair) (string, error) {
+	f, ok := LookupSymbolFormat(exchange)
+	if !ok {
+		return "", fmt.Errorf("no symbol format for exchange %q", exchange)
+	}
+	return f.Symbol(p), nil
+}
+
+// ParseSymbol parses one of the exchange's symbols, e.g. "drk_btc" on
+// Indodax gives DASH/BTC.
+func ParseSymbol(exchange, symbol string) (Pair, error) {
+	f, ok := LookupSymbolFormat(exchange)
+	if !ok {
+		return Pair{}, fmt.Errorf("no symbol format for exchange %q", exchange)
+	}
+	return f.ParseSymbol(symbol)
+}
+
+// ConvertSymbol rewrites one exchange's symbol in another's format, e.g.
+// "BTC-DASH" from Bittrex is "dshbtc" on Bitfinex.
+func ConvertSymbol(symbol, from, to string) (string, error) {
+	p, err := ParseSymbol(from, symbol)
+	if err != nil {
+		return "", err
+	}
+	return FormatSymbol(to, p)
+}
+
+// exchangeSymbol returns the symbol for base/quote on one of the package's
+// own exchanges, whose formats are always registered.
+func exchangeSymbol(exchange, base, quote string) string {
+	f, _ := LookupSymbolFormat(exchange)
+	return f.Symbol(Pair{Base: Currency(base), Quote: Currency(quote)})
+}
+
+// exchangeCurrency returns the code for c on one of the package's own
+// exchanges.
+func exchangeCurrency(exchange, c string) string {
+	f, _ := LookupSymbolFormat(exchange)
+	return f.Currency(Currency(c))
+}
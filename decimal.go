@@ -0,0 +1,327 @@
+package dashrates
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// maxDecimalExponent bounds the exponent accepted by ParseDecimal, so that a
+// malicious "1e999999999" can't make String allocate gigabytes.
+const maxDecimalExponent = 1000
+
+// Decimal is an exact decimal number. Adapters use it to carry prices and
+// volumes exactly as the exchange sent them, e.g. "0.00897700" stays
+// "0.00897700" rather than becoming 0.008977000000000001.
+//
+// The zero value is unset, meaning the value is unknown. An unset Decimal
+// marshals to JSON null, and arithmetic involving one gives an unset result.
+//
+// Decimals are immutable; the arithmetic methods return new values.
+type Decimal struct {
+	// value is coef * 10**exp. coef is nil when the Decimal is unset.
+	coef *big.Int
+	exp  int
+}
+
+// NewDecimal returns the Decimal coef * 10**exp, e.g. NewDecimal(8977, -6) is
+// 0.008977.
+func NewDecimal(coef int64, exp int) Decimal {
+	return Decimal{coef: big.NewInt(coef), exp: exp}
+}
+
+// NewDecimalFromFloat returns the shortest Decimal which converts back to f.
+// NaN and infinities give an unset Decimal.
+func NewDecimalFromFloat(f float64) Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}
+	}
+	d, _ := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	return d
+}
+
+// ParseDecimal parses a decimal number such as "0.008977", "-12", ".5" or
+// "1.5e-3", keeping every digit given, including trailing zeros.
+func ParseDecimal(s string) (Decimal, error) {
+	mant, exp := s, 0
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.Atoi(s[i+1:])
+		if err != nil {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+		mant, exp = s[:i], e
+	}
+
+	neg := false
+	if mant != "" && (mant[0] == '+' || mant[0] == '-') {
+		neg = mant[0] == '-'
+		mant = mant[1:]
+	}
+
+	intPart, fracPart := mant, ""
+	if i := strings.IndexByte(mant, '.'); i >= 0 {
+		intPart, fracPart = mant[:i], mant[i+1:]
+	}
+	digits := intPart + fracPart
+	if digits == "" {
+		return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return Decimal{}, fmt.Errorf("invalid decimal %q", s)
+		}
+	}
+
+	exp -= len(fracPart)
+	if exp > maxDecimalExponent || exp < -maxDecimalExponent {
+		return Decimal{}, fmt.Errorf("decimal %q out of range", s)
+	}
+
+	coef, _ := new(big.Int).SetString(digits, 10)
+	if neg {
+		coef.Neg(coef)
+	}
+
+	return Decimal{coef: coef, exp: exp}, nil
+}
+
+// IsSet reports whether d holds a value.
+func (d Decimal) IsSet() bool {
+	return d.coef != nil
+}
+
+// Sign returns -1, 0 or +1 depending on the sign of d. It returns 0 for an
+// unset Decimal.
+func (d Decimal) Sign() int {
+	if d.coef == nil {
+		return 0
+	}
+	return d.coef.Sign()
+}
+
+// String formats d in plain decimal notation, without an exponent. It returns
+// "" for an unset Decimal.
+func (d Decimal) String() string {
+	if d.coef == nil {
+		return ""
+	}
+
+	sign := ""
+	if d.coef.Sign() < 0 {
+		sign = "-"
+	}
+	digits := new(big.Int).Abs(d.coef).String()
+
+	if d.exp >= 0 {
+		if d.coef.Sign() == 0 {
+			return "0"
+		}
+		return sign + digits + strings.Repeat("0", d.exp)
+	}
+
+	scale := -d.exp
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	point := len(digits) - scale
+
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// Float64 returns the float64 nearest to d, or 0 for an unset Decimal.
+func (d Decimal) Float64() float64 {
+	if d.coef == nil {
+		return 0
+	}
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// Rat returns d as a big.Rat, or nil for an unset Decimal.
+func (d Decimal) Rat() *big.Rat {
+	if d.coef == nil {
+		return nil
+	}
+	if d.exp >= 0 {
+		n := new(big.Int).Mul(d.coef, pow10(d.exp))
+		return new(big.Rat).SetInt(n)
+	}
+	return new(big.Rat).SetFrac(d.coef, pow10(-d.exp))
+}
+
+// Cmp compares d and y, returning -1, 0 or +1. An unset Decimal sorts before
+// every set one.
+func (d Decimal) Cmp(y Decimal) int {
+	switch {
+	case d.coef == nil && y.coef == nil:
+		return 0
+	case d.coef == nil:
+		return -1
+	case y.coef == nil:
+		return 1
+	}
+	a, b, _ := align(d, y)
+	return a.Cmp(b)
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	if d.coef == nil {
+		return d
+	}
+	return Decimal{coef: new(big.Int).Neg(d.coef), exp: d.exp}
+}
+
+// Add returns d + y exactly.
+func (d Decimal) Add(y Decimal) Decimal {
+	if d.coef == nil || y.coef == nil {
+		return Decimal{}
+	}
+	a, b, exp := align(d, y)
+	return Decimal{coef: new(big.Int).Add(a, b), exp: exp}
+}
+
+// Sub returns d - y exactly.
+func (d Decimal) Sub(y Decimal) Decimal {
+	return d.Add(y.Neg())
+}
+
+// Mul returns d * y exactly. This is the usual way to convert a price from
+// one quote currency to another, e.g. DASH/BTC * BTC/USD.
+func (d Decimal) Mul(y Decimal) Decimal {
+	if d.coef == nil || y.coef == nil {
+		return Decimal{}
+	}
+	return Decimal{coef: new(big.Int).Mul(d.coef, y.coef), exp: d.exp + y.exp}
+}
+
+// Div returns d / y rounded to places digits after the decimal point, with
+// halves rounded away from zero. Dividing by zero gives an unset Decimal.
+func (d Decimal) Div(y Decimal, places int) Decimal {
+	if d.coef == nil || y.coef == nil || y.coef.Sign() == 0 {
+		return Decimal{}
+	}
+	q := new(big.Rat).Quo(d.Rat(), y.Rat())
+	return roundRat(q, places)
+}
+
+// Inv returns 1 / d rounded to places digits after the decimal point, e.g. to
+// turn a DASH/BTC price into a BTC/DASH one.
+func (d Decimal) Inv(places int) Decimal {
+	return NewDecimal(1, 0).Div(d, places)
+}
+
+// Round returns d rounded to places digits after the decimal point, with
+// halves rounded away from zero. Values which already have no more than
+// places digits are returned unchanged, trailing zeros included.
+func (d Decimal) Round(places int) Decimal {
+	if d.coef == nil || -d.exp <= places {
+		return d
+	}
+	return roundRat(d.Rat(), places)
+}
+
+// MarshalJSON encodes d as a JSON string, so that no precision is lost by
+// consumers which decode numbers as floats. An unset Decimal encodes as null.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	if d.coef == nil {
+		return []byte("null"), nil
+	}
+	return []byte(`"` + d.String() + `"`), nil
+}
+
+// UnmarshalJSON decodes a JSON string or number into d, keeping every digit.
+// A JSON null gives an unset Decimal.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		*d = Decimal{}
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		var err error
+		s, err = strconv.Unquote(s)
+		if err != nil {
+			return fmt.Errorf("invalid decimal %s", data)
+		}
+	}
+
+	x, err := ParseDecimal(s)
+	if err != nil {
+		return err
+	}
+	*d = x
+
+	return nil
+}
+
+// MarshalBinary is part of the encoding.BinaryMarshaler interface. An unset
+// Decimal encodes as no bytes.
+func (d Decimal) MarshalBinary() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalBinary is part of the encoding.BinaryUnmarshaler interface
+func (d *Decimal) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		*d = Decimal{}
+		return nil
+	}
+	x, err := ParseDecimal(string(data))
+	if err != nil {
+		return err
+	}
+	*d = x
+	return nil
+}
+
+// align returns the coefficients of x and y rescaled to a common exponent,
+// along with that exponent.
+func align(x, y Decimal) (*big.Int, *big.Int, int) {
+	switch {
+	case x.exp > y.exp:
+		a := new(big.Int).Mul(x.coef, pow10(x.exp-y.exp))
+		return a, y.coef, y.exp
+	case y.exp > x.exp:
+		b := new(big.Int).Mul(y.coef, pow10(y.exp-x.exp))
+		return x.coef, b, x.exp
+	}
+	return x.coef, y.coef, x.exp
+}
+
+// roundRat rounds r to places digits after the decimal point, with halves
+// rounded away from zero.
+func roundRat(r *big.Rat, places int) Decimal {
+	n := new(big.Rat).Set(r)
+	if places >= 0 {
+		n.Mul(n, new(big.Rat).SetInt(pow10(places)))
+	} else {
+		n.Quo(n, new(big.Rat).SetInt(pow10(-places)))
+	}
+
+	num, den := n.Num(), n.Denom()
+	q, m := new(big.Int).QuoRem(num, den, new(big.Int))
+	m.Abs(m).Lsh(m, 1)
+	if m.Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(int64(num.Sign())))
+	}
+
+	return Decimal{coef: q, exp: -places}
+}
+
+// pow10 returns 10**n for n >= 0.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// optionalDecimal parses s, giving an unset Decimal instead of an error when
+// s isn't a number. It is used for fields which an exchange may leave empty.
+func optionalDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		return Decimal{}
+	}
+	return d
+}
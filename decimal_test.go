@@ -0,0 +1,248 @@
+package dashrates
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustDecimal(t *testing.T, s string) Decimal {
+	t.Helper()
+	d, err := ParseDecimal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"0.00897700", "0.00897700"},
+		{"100", "100"},
+		{"-12", "-12"},
+		{"-0.50", "-0.50"},
+		{"+1", "1"},
+		{".5", "0.5"},
+		{"5.", "5"},
+		{"1.5e-3", "0.0015"},
+		{"1.50E2", "150"},
+		{"1e3", "1000"},
+		{"-0.0", "0.0"},
+		{"1e-1000", "0." + strings.Repeat("0", 999) + "1"},
+	}
+	for _, tt := range tests {
+		d, err := ParseDecimal(tt.in)
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if got := d.String(); got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "-", ".", "1.2.3", "abc", "1,5", "0x10", "1e", "1e1.5", "1e1001", "1e-1001", "NaN"} {
+		if d, err := ParseDecimal(bad); err == nil {
+			t.Errorf("%q: got %s, want an error", bad, d)
+		}
+	}
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		in     string
+		places int
+		want   string
+	}{
+		{"1.005", 2, "1.01"},
+		{"-1.005", 2, "-1.01"},
+		{"1.004", 2, "1.00"},
+		{"2.5", 0, "3"},
+		{"-2.5", 0, "-3"},
+		{"1.5", 2, "1.5"},
+		{"1.50", 4, "1.50"},
+		{"1250", -2, "1300"},
+		{"-1250", -2, "-1300"},
+		{"1249", -2, "1200"},
+		{"49", -2, "0"},
+	}
+	for _, tt := range tests {
+		if got := mustDecimal(t, tt.in).Round(tt.places).String(); got != tt.want {
+			t.Errorf("%s rounded to %d places: got %s, want %s", tt.in, tt.places, got, tt.want)
+		}
+	}
+	if (Decimal{}).Round(2).IsSet() {
+		t.Error("rounding an unset Decimal set it")
+	}
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Decimal
+		want string
+	}{
+		{"add", mustDecimal(t, "1.5").Add(mustDecimal(t, "0.25")), "1.75"},
+		{"add negative", mustDecimal(t, "1").Add(mustDecimal(t, "-1.10")), "-0.10"},
+		{"add exponents", mustDecimal(t, "1e2").Add(mustDecimal(t, "0.1")), "100.1"},
+		{"sub", mustDecimal(t, "0.3").Sub(mustDecimal(t, "0.1")), "0.2"},
+		{"mul", mustDecimal(t, "0.008977").Mul(mustDecimal(t, "60000")), "538.620000"},
+		{"mul negative", mustDecimal(t, "-1.5").Mul(mustDecimal(t, "2")), "-3.0"},
+		{"div", mustDecimal(t, "1").Div(mustDecimal(t, "3"), 4), "0.3333"},
+		{"div rounds half away", mustDecimal(t, "-2").Div(mustDecimal(t, "3"), 4), "-0.6667"},
+		{"div exact", mustDecimal(t, "1").Div(mustDecimal(t, "8"), 3), "0.125"},
+		{"div by zero", mustDecimal(t, "1").Div(mustDecimal(t, "0.00"), 4), ""},
+		{"div unset", mustDecimal(t, "1").Div(Decimal{}, 4), ""},
+		{"inv", mustDecimal(t, "0.008977").Inv(8), "111.39578924"},
+		{"add unset", mustDecimal(t, "1").Add(Decimal{}), ""},
+		{"mul unset", Decimal{}.Mul(mustDecimal(t, "1")), ""},
+		{"neg", mustDecimal(t, "1.50").Neg(), "-1.50"},
+		{"new", NewDecimal(8977, -6), "0.008977"},
+		{"from float", NewDecimalFromFloat(0.008977), "0.008977"},
+	}
+	for _, tt := range tests {
+		if got := tt.got.String(); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalCmp(t *testing.T) {
+	tests := []struct {
+		x, y Decimal
+		want int
+	}{
+		{mustDecimal(t, "1.50"), mustDecimal(t, "1.5"), 0},
+		{mustDecimal(t, "1e2"), mustDecimal(t, "100.00"), 0},
+		{mustDecimal(t, "-2"), mustDecimal(t, "1"), -1},
+		{mustDecimal(t, "0.1"), mustDecimal(t, "0.09"), 1},
+		{mustDecimal(t, "-0.1"), mustDecimal(t, "-0.09"), -1},
+		{Decimal{}, mustDecimal(t, "-100"), -1},
+		{mustDecimal(t, "0"), Decimal{}, 1},
+		{Decimal{}, Decimal{}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.x.Cmp(tt.y); got != tt.want {
+			t.Errorf("%q cmp %q: got %d, want %d", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestDecimalJSON(t *testing.T) {
+	type doc struct {
+		Price  Decimal
+		Volume Decimal
+	}
+
+	data, err := json.Marshal(doc{Price: mustDecimal(t, "-1.50")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), `{"Price":"-1.50","Volume":null}`; got != want {
+		t.Errorf("marshal: got %s, want %s", got, want)
+	}
+
+	tests := []struct {
+		in         string
+		wantPrice  string
+		wantVolume string
+	}{
+		{`{"Price":"-1.50","Volume":null}`, "-1.50", ""},
+		{`{"Price":1.50,"Volume":"0.00"}`, "1.50", "0.00"},
+		{`{"Price":1e-8}`, "0.00000001", ""},
+		{`{}`, "", ""},
+	}
+	for _, tt := range tests {
+		var d doc
+		if err := json.Unmarshal([]byte(tt.in), &d); err != nil {
+			t.Errorf("%s: %v", tt.in, err)
+			continue
+		}
+		if d.Price.String() != tt.wantPrice || d.Volume.String() != tt.wantVolume {
+			t.Errorf("%s: got %q and %q, want %q and %q", tt.in, d.Price, d.Volume, tt.wantPrice, tt.wantVolume)
+		}
+	}
+
+	for _, bad := range []string{`{"Price":"x"}`, `{"Price":"1e9999"}`, `{"Price":true}`, `{"Price":"1.5`} {
+		var d doc
+		if err := json.Unmarshal([]byte(bad), &d); err == nil {
+			t.Errorf("%s: got %s, want an error", bad, d.Price)
+		}
+	}
+}
+
+func TestDecimalBinary(t *testing.T) {
+	for _, in := range []Decimal{mustDecimal(t, "0.00897700"), mustDecimal(t, "-12"), {}} {
+		data, err := in.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out Decimal
+		if err := out.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != in.String() || out.IsSet() != in.IsSet() {
+			t.Errorf("%q: round trip gave %q", in, out)
+		}
+	}
+}
+
+func TestRateInfoBinaryRoundTrip(t *testing.T) {
+	fetched := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	in := &RateInfo{
+		BaseCurrency:           "DASH",
+		QuoteCurrency:          "USD",
+		LastPrice:              28.5,
+		LastPriceDecimal:       mustDecimal(t, "28.50000000"),
+		BaseAssetVolume:        1234.5,
+		BaseAssetVolumeDecimal: mustDecimal(t, "1234.50"),
+		Ask:                    mustDecimal(t, "28.51"),
+		QuoteAssetVolume:       mustDecimal(t, "-0.0"),
+		FetchTime:              fetched,
+		ServerTime:             fetched.Add(-time.Second),
+		Conversions: []Conversion{{
+			Source:        "Kraken",
+			BaseCurrency:  "USDT",
+			QuoteCurrency: "USD",
+			Rate:          mustDecimal(t, "1.0002"),
+			FetchTime:     fetched,
+		}},
+	}
+
+	data, err := in.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out RateInfo
+	if err := out.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+
+	decimals := []struct {
+		name     string
+		got, was Decimal
+	}{
+		{"LastPriceDecimal", out.LastPriceDecimal, in.LastPriceDecimal},
+		{"BaseAssetVolumeDecimal", out.BaseAssetVolumeDecimal, in.BaseAssetVolumeDecimal},
+		{"Bid", out.Bid, in.Bid},
+		{"Ask", out.Ask, in.Ask},
+		{"QuoteAssetVolume", out.QuoteAssetVolume, in.QuoteAssetVolume},
+	}
+	for _, d := range decimals {
+		if d.got.String() != d.was.String() || d.got.IsSet() != d.was.IsSet() {
+			t.Errorf("%s: got %q, want %q", d.name, d.got, d.was)
+		}
+	}
+	if out.BaseCurrency != "DASH" || out.QuoteCurrency != "USD" || out.LastPrice != 28.5 || out.BaseAssetVolume != 1234.5 {
+		t.Errorf("got %+v", out)
+	}
+	if !out.FetchTime.Equal(in.FetchTime) || !out.ServerTime.Equal(in.ServerTime) {
+		t.Errorf("times: got %s and %s, want %s and %s", out.FetchTime, out.ServerTime, in.FetchTime, in.ServerTime)
+	}
+	if len(out.Conversions) != 1 || out.Conversions[0].Rate.String() != "1.0002" || out.Conversions[0].Source != "Kraken" {
+		t.Errorf("conversions: got %+v", out.Conversions)
+	}
+}
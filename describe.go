@@ -0,0 +1,76 @@
+package dashrates
+
+// PriceType says what sort of price an adapter reports as LastPrice.
+type PriceType string
+
+// The kinds of price adapters report.
+const (
+	// LastTradePrice is the price of the most recent trade.
+	LastTradePrice PriceType = "last"
+
+	// MidPrice is halfway between the best bid and ask.
+	MidPrice PriceType = "mid"
+
+	// BidPrice is the best bid, i.e. what the exchange would pay.
+	BidPrice PriceType = "bid"
+
+	// AskPrice is the best ask, i.e. what the exchange would charge.
+	AskPrice PriceType = "ask"
+
+	// IndicativePrice is a reference rate which may not correspond to any
+	// trade or order, such as an index or a broker's quoted rate.
+	IndicativePrice PriceType = "indicative"
+)
+
+// Description is metadata about an adapter, so that consumers such as
+// aggregators can treat each source appropriately.
+type Description struct {
+	// ID is the adapter's registry ID, e.g. "kraken".
+	ID string
+
+	// Name is the exchange display name.
+	Name string
+
+	// Pair is the pair the adapter fetches.
+	Pair Pair
+
+	// Pairs lists the pairs the adapter's ForPair constructor accepts. It is
+	// empty for adapters which accept any pair, and find out whether the
+	// exchange knows it when fetching.
+	Pairs []Pair
+
+	// Price is the sort of price reported as LastPrice.
+	Price PriceType
+
+	// HasVolume reports whether BaseAssetVolume is provided. When false,
+	// BaseAssetVolume is 0 even though the true volume isn't.
+	HasVolume bool
+
+	// URL is the exchange's website.
+	URL string
+
+	// Deprecated, if non-empty, says why the adapter shouldn't be relied on,
+	// e.g. because the exchange has closed.
+	Deprecated string
+}
+
+// DescribedAPI is a RateAPI which can describe itself. Every adapter in the
+// package implements it.
+type DescribedAPI interface {
+	RateAPI
+	Describe() Description
+}
+
+// parsePairs converts a list of "BASE/QUOTE" strings, as used by the
+// adapters, into Pairs.
+func parsePairs(pairs []string) []Pair {
+	out := make([]Pair, 0, len(pairs))
+	for _, s := range pairs {
+		p, err := ParsePair(s)
+		if err != nil {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
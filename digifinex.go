@@ -1,9 +1,9 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
+	"errors"
+	"strconv"
 	"time"
 )
 
@@ -12,52 +12,116 @@ import (
 type DigifinexAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("digifinex", func(opts ...Option) RateAPI { return NewDigifinexAPI(opts...) })
 }
 
 // NewDigifinexAPI is a constructor for DigifinexAPI.
-func NewDigifinexAPI() *DigifinexAPI {
+func NewDigifinexAPI(opts ...Option) *DigifinexAPI {
+	a, _ := NewDigifinexAPIForPair("DASH", "BTC", opts...)
+	return a
+}
+
+// NewDigifinexAPIForPair is a constructor for a DigifinexAPI which fetches the
+// rate for the base/quote pair, e.g. ("DASH", "BTC"). It returns an error
+// wrapping ErrPairNotFound for pairs not in digifinexPairs.
+func NewDigifinexAPIForPair(base, quote string, opts ...Option) (*DigifinexAPI, error) {
+	base, quote, err := checkPair("Digifinex", digifinexPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &DigifinexAPI{
 		BaseAPIURL:          "https://openapi.digifinex.com",
-		PriceTickerEndpoint: "/v3/ticker?symbol=dash_btc",
-	}
+		PriceTickerEndpoint: "/v3/ticker?symbol=" + exchangeSymbol("digifinex", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// digifinexPairs lists the Digifinex markets which NewDigifinexAPIForPair
+// accepts.
+var digifinexPairs = []string{"DASH/BTC", "DASH/USDT"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *DigifinexAPI) DisplayName() string {
 	return "Digifinex"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *DigifinexAPI) Describe() Description {
+	return Description{
+		ID:        "digifinex",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(digifinexPairs),
+		Price:     LastTradePrice,
+		HasVolume: true,
+		URL:       "https://www.digifinex.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Digifinex API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *DigifinexAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the Digifinex API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *DigifinexAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res digifinexPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if res.Code != 0 {
+		return nil, &ExchangeError{
+			Exchange: a.DisplayName(),
+			Code:     strconv.FormatInt(res.Code, 10),
+			Msg:      res.Msg,
+			Err:      digifinexErrorClass(res.Code),
+		}
 	}
 
-	// parse json and extract Dash rate
-	var res digifinexPubTickerResp
-	err = json.Unmarshal(body, &res)
-	if err != nil {
-		return nil, err
+	if len(res.Ticker) == 0 {
+		return nil, badResponse(errors.New("no tickers in response"))
+	}
+
+	if !res.Ticker[0].Last.IsSet() {
+		return nil, badResponse(errors.New("no price in response"))
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "BTC",
-		LastPrice:       res.Ticker[0].Last,
-		BaseAssetVolume: res.Ticker[0].BaseVol,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              res.Ticker[0].Last.Float64(),
+		LastPriceDecimal:       res.Ticker[0].Last,
+		BaseAssetVolume:        res.Ticker[0].BaseVol.Float64(),
+		BaseAssetVolumeDecimal: res.Ticker[0].BaseVol,
+		Bid:                    res.Ticker[0].Buy,
+		Ask:                    res.Ticker[0].Sell,
+		High:                   res.Ticker[0].High,
+		Low:                    res.Ticker[0].Low,
+		QuoteAssetVolume:       res.Ticker[0].Vol,
+		FetchTime:              now,
+		ServerTime:             unixTime(res.Date),
 	}
 
 	return &ri, nil
@@ -68,17 +132,27 @@ type digifinexPubTickerResp struct {
 	Ticker []digifinexPubTickerData `json:"ticker"`
 	Date   int64                    `json:"date"`
 	Code   int64                    `json:"code"`
+	Msg    string                   `json:"msg"`
+}
+
+// digifinexErrorClass returns the sentinel error matching a Digifinex error
+// code, or nil if there isn't one.
+func digifinexErrorClass(code int64) error {
+	if code == 10005 {
+		return ErrRateLimited
+	}
+	return nil
 }
 
 // digifinexPubTickerData is used in parsing the Digifinex API response only.
 type digifinexPubTickerData struct {
-	Vol     float64 `json:"vol"`
+	Vol     Decimal `json:"vol"`
 	Change  float64 `json:"change"`
-	BaseVol float64 `json:"base_vol"`
-	Sell    float64 `json:"sell"`
-	Last    float64 `json:"last"`
+	BaseVol Decimal `json:"base_vol"`
+	Sell    Decimal `json:"sell"`
+	Last    Decimal `json:"last"`
 	Symbol  string  `json:"symbol"`
-	Low     float64 `json:"low"`
-	Buy     float64 `json:"buy"`
-	High    float64 `json:"high"`
+	Low     Decimal `json:"low"`
+	Buy     Decimal `json:"buy"`
+	High    Decimal `json:"high"`
 }
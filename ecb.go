@@ -0,0 +1,193 @@
+package dashrates
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+// ECBAPI is an FXSource using the European Central Bank's euro foreign
+// exchange reference rates, which are published as XML once each working
+// day, around 16:00 CET.
+type ECBAPI struct {
+	BaseAPIURL    string
+	DailyEndpoint string
+	Client        Doer
+}
+
+// NewECBAPI is a constructor for ECBAPI.
+func NewECBAPI(opts ...Option) *ECBAPI {
+	o := newOptions(opts)
+	return &ECBAPI{
+		BaseAPIURL:    "https://www.ecb.europa.eu",
+		DailyEndpoint: "/stats/eurofxref/eurofxref-daily.xml",
+		Client:        o.client,
+	}
+}
+
+// DisplayName returns the FX source display name. It is part of the FXSource
+// interface implementation.
+func (a *ECBAPI) DisplayName() string {
+	return "ECB"
+}
+
+// FetchRates gets the latest reference rates.
+func (a *ECBAPI) FetchRates(ctx context.Context) (*ECBRates, error) {
+	var res ecbEnvelope
+	err := getXML(ctx, a.Client, a.BaseAPIURL+a.DailyEndpoint, &res)
+	if err != nil {
+		return nil, err
+	}
+
+	days, err := res.Normalize(time.Now())
+	if err != nil {
+		return nil, badResponse(err)
+	}
+	return days[0], nil
+}
+
+// FetchFXRate gets the price of one unit of base in quote from the latest
+// reference rates.
+//
+// This is part of the FXSource interface implementation.
+func (a *ECBAPI) FetchFXRate(ctx context.Context, base, quote Currency) (*RateInfo, error) {
+	rates, err := a.FetchRates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rates.Rate(base, quote)
+}
+
+// ECBRates is one day's ECB reference rates.
+type ECBRates struct {
+	// Date is the day the rates were published, as midnight UTC.
+	Date time.Time
+
+	// Rates gives the price of one euro in each currency, e.g. USD 1.0921.
+	Rates map[Currency]Decimal
+
+	// FetchTime is when the rates were fetched.
+	FetchTime time.Time
+}
+
+// ParseECBRates parses an ECB eurofxref XML document, such as
+// eurofxref-daily.xml or eurofxref-hist-90d.xml, returning each day's rates
+// with the most recent first.
+func ParseECBRates(data []byte) ([]*ECBRates, error) {
+	var res ecbEnvelope
+	if err := xml.Unmarshal(data, &res); err != nil {
+		return nil, err
+	}
+	return res.Normalize(time.Time{})
+}
+
+// Rate returns the price of one unit of base in quote as a RateInfo, with
+// the publication date as its ServerTime. The ECB only publishes prices of
+// the euro, so any other pair is a cross rate through EUR, rounded to 16
+// significant digits.
+func (r *ECBRates) Rate(base, quote Currency) (*RateInfo, error) {
+	perEUR := func(c Currency) (Decimal, bool) {
+		if c == EUR {
+			return NewDecimal(1, 0), true
+		}
+		d, ok := r.Rates[c]
+		return d, ok
+	}
+	b, okB := perEUR(base)
+	q, okQ := perEUR(quote)
+	if !okB || !okQ || base == quote {
+		return nil, pairNotFound("ECB", string(base), string(quote))
+	}
+
+	price := q
+	if base != EUR {
+		price = roundSignificant(new(big.Rat).Quo(q.Rat(), b.Rat()), crossRateDigits)
+	}
+
+	return &RateInfo{
+		BaseCurrency:     string(base),
+		QuoteCurrency:    string(quote),
+		LastPrice:        price.Float64(),
+		LastPriceDecimal: price,
+		FetchTime:        r.FetchTime,
+		ServerTime:       r.Date,
+	}, nil
+}
+
+// RateInfos returns every rate as a EUR-based RateInfo, e.g. EUR/USD, sorted
+// by quote currency. Adding them to a RateGraph gives every cross rate.
+func (r *ECBRates) RateInfos() []*RateInfo {
+	quotes := make([]string, 0, len(r.Rates))
+	for c := range r.Rates {
+		quotes = append(quotes, string(c))
+	}
+	sort.Strings(quotes)
+
+	infos := make([]*RateInfo, 0, len(quotes))
+	for _, q := range quotes {
+		ri, err := r.Rate(EUR, Currency(q))
+		if err == nil {
+			infos = append(infos, ri)
+		}
+	}
+	return infos
+}
+
+// ecbEnvelope is used in parsing the ECB XML document only. The rates are
+// nested in three levels of Cube elements: the outer one, one per day, and
+// one per currency.
+type ecbEnvelope struct {
+	Cube struct {
+		Days []struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string `xml:"currency,attr"`
+				Rate     string `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// Normalize parses the fields in ecbEnvelope and returns each day's rates,
+// most recent first, with fetchTime as their FetchTime.
+func (env *ecbEnvelope) Normalize(fetchTime time.Time) ([]*ECBRates, error) {
+	if len(env.Cube.Days) == 0 {
+		return nil, errors.New("no rates in ECB document")
+	}
+
+	days := make([]*ECBRates, 0, len(env.Cube.Days))
+	for _, day := range env.Cube.Days {
+		date, err := time.Parse("2006-01-02", day.Time)
+		if err != nil {
+			return nil, err
+		}
+
+		rates := make(map[Currency]Decimal, len(day.Rates))
+		for _, r := range day.Rates {
+			rate, err := ParseDecimal(r.Rate)
+			if err != nil {
+				return nil, err
+			}
+			if rate.Sign() <= 0 {
+				return nil, fmt.Errorf("invalid ECB rate %q for %s", r.Rate, r.Currency)
+			}
+			rates[Currency(r.Currency)] = rate
+		}
+
+		days = append(days, &ECBRates{
+			Date:      date,
+			Rates:     rates,
+			FetchTime: fetchTime,
+		})
+	}
+
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Date.After(days[j].Date)
+	})
+
+	return days, nil
+}
@@ -0,0 +1,156 @@
+package dashrates
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func readECBSample(t *testing.T) []byte {
+	t.Helper()
+	data, err := ioutil.ReadFile("testdata/eurofxref-daily.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestParseECBRates(t *testing.T) {
+	days, err := ParseECBRates(readECBSample(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 1 {
+		t.Fatalf("got %d days, want 1", len(days))
+	}
+
+	day := days[0]
+	if want := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC); !day.Date.Equal(want) {
+		t.Errorf("date: got %s, want %s", day.Date, want)
+	}
+	if len(day.Rates) != 6 {
+		t.Errorf("got %d rates, want 6", len(day.Rates))
+	}
+	if got := day.Rates[GBP].String(); got != "0.86810" {
+		t.Errorf("GBP: got %s, want 0.86810", got)
+	}
+}
+
+func TestParseECBRatesHistory(t *testing.T) {
+	doc := `<Envelope><Cube>
+		<Cube time="2024-01-02"><Cube currency="USD" rate="1.0956"/></Cube>
+		<Cube time="2024-01-03"><Cube currency="USD" rate="1.0919"/></Cube>
+	</Cube></Envelope>`
+	days, err := ParseECBRates([]byte(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 2 || days[0].Date.Day() != 3 || days[1].Date.Day() != 2 {
+		t.Fatalf("want both days, most recent first, got %+v", days)
+	}
+
+	for _, bad := range []string{
+		`<Envelope><Cube></Cube></Envelope>`,
+		`<Envelope><Cube><Cube time="yesterday"><Cube currency="USD" rate="1"/></Cube></Cube></Envelope>`,
+		`<Envelope><Cube><Cube time="2024-01-02"><Cube currency="USD" rate="0"/></Cube></Cube></Envelope>`,
+		`<Envelope><Cube><Cube time="2024-01-02"><Cube currency="USD" rate="x"/></Cube></Cube></Envelope>`,
+	} {
+		if _, err := ParseECBRates([]byte(bad)); err == nil {
+			t.Errorf("no error parsing %s", bad)
+		}
+	}
+}
+
+func TestECBRatesRate(t *testing.T) {
+	days, err := ParseECBRates(readECBSample(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rates := days[0]
+
+	tests := []struct {
+		base, quote Currency
+		want        string
+	}{
+		{EUR, USD, "1.0956"},
+		{USD, EUR, "0.9127418765972983"},
+		{USD, GBP, "0.7923512230741146"},
+		{USD, Currency("JPY"), "141.9496166484118"},
+	}
+	for _, tt := range tests {
+		ri, err := rates.Rate(tt.base, tt.quote)
+		if err != nil {
+			t.Errorf("%s/%s: %v", tt.base, tt.quote, err)
+			continue
+		}
+		if got := ri.LastPriceDecimal.String(); got != tt.want {
+			t.Errorf("%s/%s: got %s, want %s", tt.base, tt.quote, got, tt.want)
+		}
+		if ri.BaseCurrency != string(tt.base) || ri.QuoteCurrency != string(tt.quote) {
+			t.Errorf("%s/%s: got pair %s/%s", tt.base, tt.quote, ri.BaseCurrency, ri.QuoteCurrency)
+		}
+		if !ri.ServerTime.Equal(rates.Date) {
+			t.Errorf("%s/%s: ServerTime %s, want the publication date", tt.base, tt.quote, ri.ServerTime)
+		}
+	}
+
+	for _, pair := range [][2]Currency{{USD, RUB}, {RUB, EUR}, {EUR, EUR}} {
+		if _, err := rates.Rate(pair[0], pair[1]); !errors.Is(err, ErrPairNotFound) {
+			t.Errorf("%s/%s: got %v, want ErrPairNotFound", pair[0], pair[1], err)
+		}
+	}
+}
+
+func TestECBRatesRateInfos(t *testing.T) {
+	days, err := ParseECBRates(readECBSample(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	infos := days[0].RateInfos()
+	var quotes []string
+	for _, ri := range infos {
+		if ri.BaseCurrency != "EUR" {
+			t.Errorf("base: got %s, want EUR", ri.BaseCurrency)
+		}
+		quotes = append(quotes, ri.QuoteCurrency)
+	}
+	want := []string{"CHF", "GBP", "IDR", "INR", "JPY", "USD"}
+	if len(quotes) != len(want) {
+		t.Fatalf("got quotes %v, want %v", quotes, want)
+	}
+	for i := range want {
+		if quotes[i] != want[i] {
+			t.Fatalf("got quotes %v, want %v", quotes, want)
+		}
+	}
+
+	// every cross rate is reachable through a RateGraph
+	g := NewRateGraph()
+	for _, ri := range infos {
+		g.Add("ECB", ri)
+	}
+	ri, err := g.Rate(NewPair("USD", "GBP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ri.LastPriceDecimal.String(); got != "0.7923512230741146" {
+		t.Errorf("USD/GBP via RateGraph: got %s", got)
+	}
+}
+
+func TestECBAPIFetchFXRate(t *testing.T) {
+	api := NewECBAPI(WithHTTPClient(fakeDoer{body: readECBSample(t)}))
+	ri, err := api.FetchFXRate(context.Background(), GBP, USD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ri.LastPriceDecimal.String(); got != "1.262066582190992" {
+		t.Errorf("GBP/USD: got %s", got)
+	}
+	if ri.FetchTime.IsZero() {
+		t.Error("FetchTime not set")
+	}
+}
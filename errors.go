@@ -0,0 +1,127 @@
+package dashrates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Errors returned by the adapters can be tested against these with
+// errors.Is to find out what sort of failure occurred, whatever the exchange.
+var (
+	// ErrPairNotFound means the exchange doesn't list the requested pair, or
+	// has delisted it.
+	ErrPairNotFound = errors.New("pair not found")
+
+	// ErrRateLimited means the exchange refused the request because too many
+	// have been made recently.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrExchangeUnavailable means the exchange couldn't be reached, timed
+	// out, or reported that it is down or under maintenance.
+	ErrExchangeUnavailable = errors.New("exchange unavailable")
+
+	// ErrBadResponse means the exchange answered, but with something that
+	// couldn't be understood as a price ticker.
+	ErrBadResponse = errors.New("bad response")
+)
+
+// ExchangeError is an error reported by, or attributed to, a particular
+// exchange. Code and Msg carry the exchange's own error code and message when
+// it supplied them.
+//
+// Err holds the underlying cause, which is usually one of the package's
+// sentinel errors, so errors.Is(err, ErrPairNotFound) and friends see through
+// an ExchangeError. It is nil when the exchange reported an error code that
+// the package doesn't know how to classify.
+type ExchangeError struct {
+	Exchange string
+	Code     string
+	Msg      string
+	Err      error
+}
+
+// Error is part of the error interface.
+func (e *ExchangeError) Error() string {
+	msg := e.Msg
+	if msg == "" && e.Err != nil {
+		msg = e.Err.Error()
+	}
+	if msg == "" {
+		msg = "unknown error"
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("%s: error %s: %s", e.Exchange, e.Code, msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Exchange, msg)
+}
+
+// Unwrap returns the underlying cause so that errors.Is and errors.As can
+// inspect it.
+func (e *ExchangeError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether an HTTPError falls into the class of target, so that
+// e.g. a 429 response matches ErrRateLimited. A 404 matches ErrPairNotFound,
+// as several exchanges put the pair in the URL path and answer a delisted
+// one that way.
+func (e *HTTPError) Is(target error) bool {
+	switch {
+	case e.StatusCode == http.StatusTooManyRequests:
+		return target == ErrRateLimited
+	case e.StatusCode == http.StatusNotFound:
+		return target == ErrPairNotFound
+	case e.StatusCode >= 500:
+		return target == ErrExchangeUnavailable
+	default:
+		return target == ErrBadResponse
+	}
+}
+
+// classifiedError tags an error with one of the sentinel errors while still
+// exposing the original to errors.Is and errors.As.
+type classifiedError struct {
+	class error
+	err   error
+}
+
+// Error is part of the error interface.
+func (e *classifiedError) Error() string {
+	return e.class.Error() + ": " + e.err.Error()
+}
+
+// Unwrap returns the original error.
+func (e *classifiedError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is the class the error was tagged with.
+func (e *classifiedError) Is(target error) bool {
+	return target == e.class
+}
+
+// badResponse marks err, typically from parsing, as an ErrBadResponse.
+func badResponse(err error) error {
+	return &classifiedError{class: ErrBadResponse, err: err}
+}
+
+// unavailable marks err, typically from the transport, as an
+// ErrExchangeUnavailable. Cancellation by the caller isn't the exchange's
+// fault, so context.Canceled is passed through untouched.
+func unavailable(err error) error {
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+	return &classifiedError{class: ErrExchangeUnavailable, err: err}
+}
+
+// pairNotFound reports that exchange doesn't have the base/quote pair.
+func pairNotFound(exchange, base, quote string) error {
+	return &ExchangeError{
+		Exchange: exchange,
+		Msg:      fmt.Sprintf("%s/%s pair not found", base, quote),
+		Err:      ErrPairNotFound,
+	}
+}
@@ -0,0 +1,65 @@
+package dashrates
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHTTPErrorClass(t *testing.T) {
+	classes := []error{ErrPairNotFound, ErrRateLimited, ErrExchangeUnavailable, ErrBadResponse}
+	tests := []struct {
+		status int
+		want   error
+	}{
+		{400, ErrBadResponse},
+		{403, ErrBadResponse},
+		{404, ErrPairNotFound},
+		{429, ErrRateLimited},
+		{500, ErrExchangeUnavailable},
+		{503, ErrExchangeUnavailable},
+	}
+	for _, tt := range tests {
+		// BigONE puts the pair in the URL path
+		_, err := NewBigONEAPI(WithHTTPClient(fakeDoer{status: tt.status})).FetchRate()
+		for _, class := range classes {
+			if got := errors.Is(err, class); got != (class == tt.want) {
+				t.Errorf("%d: errors.Is(%v, %v) = %v", tt.status, err, class, got)
+			}
+		}
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != tt.status {
+			t.Errorf("%d: got %v, want an *HTTPError", tt.status, err)
+		}
+	}
+}
+
+func TestBinanceErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		want     error
+		wantCode string
+	}{
+		{"unknown symbol", 400, `{"code":-1121,"msg":"Invalid symbol."}`, ErrPairNotFound, "-1121"},
+		{"unknown code", 400, `{"code":-1100,"msg":"Illegal characters found in parameter."}`, ErrBadResponse, "-1100"},
+		{"rate limited", 429, `{"code":-1003,"msg":"Too many requests."}`, ErrRateLimited, "-1003"},
+		{"not an error object", 502, `<html>Bad Gateway</html>`, ErrExchangeUnavailable, ""},
+	}
+	for _, tt := range tests {
+		api := NewBinanceAPI(WithHTTPClient(fakeDoer{status: tt.status, body: []byte(tt.body)}))
+		_, err := api.FetchRate()
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.want)
+		}
+
+		var exErr *ExchangeError
+		if errors.As(err, &exErr) {
+			if exErr.Code != tt.wantCode {
+				t.Errorf("%s: got code %q, want %q", tt.name, exErr.Code, tt.wantCode)
+			}
+		} else if tt.wantCode != "" {
+			t.Errorf("%s: got %v, want an *ExchangeError", tt.name, err)
+		}
+	}
+}
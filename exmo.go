@@ -1,10 +1,7 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
+	"context"
 	"strconv"
 	"time"
 )
@@ -14,61 +11,115 @@ import (
 type ExmoAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("exmo", func(opts ...Option) RateAPI { return NewExmoAPI(opts...) })
 }
 
 // NewExmoAPI is a constructor for ExmoAPI.
-func NewExmoAPI() *ExmoAPI {
+func NewExmoAPI(opts ...Option) *ExmoAPI {
+	a, _ := NewExmoAPIForPair("DASH", "USD", opts...)
+	return a
+}
+
+// NewExmoAPIForPair is a constructor for an ExmoAPI which fetches the rate for
+// the base/quote pair, e.g. ("DASH", "USD"). It returns an error wrapping
+// ErrPairNotFound for pairs not in exmoPairs.
+func NewExmoAPIForPair(base, quote string, opts ...Option) (*ExmoAPI, error) {
+	base, quote, err := checkPair("Exmo", exmoPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &ExmoAPI{
 		BaseAPIURL:          "https://api.exmo.com",
 		PriceTickerEndpoint: "/v1/ticker/",
-	}
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// exmoPairs lists the Exmo markets which NewExmoAPIForPair accepts.
+var exmoPairs = []string{"DASH/USD", "DASH/BTC", "DASH/RUB"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *ExmoAPI) DisplayName() string {
 	return "Exmo"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *ExmoAPI) Describe() Description {
+	return Description{
+		ID:        "exmo",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(exmoPairs),
+		Price:     LastTradePrice,
+		HasVolume: true,
+		URL:       "https://exmo.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Exmo API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *ExmoAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the Exmo API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *ExmoAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res exmoPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	pair, ok := res[exchangeSymbol("exmo", a.BaseCurrency, a.QuoteCurrency)]
+	if !ok {
+		return nil, pairNotFound(a.DisplayName(), a.BaseCurrency, a.QuoteCurrency)
+	}
+	data, err := pair.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
-	// parse json and extract Dash rate
-	var res exmoPubTickerResp
-	err = json.Unmarshal(body, &res)
+	last, err := ParseDecimal(pair.Last)
 	if err != nil {
-		return nil, err
-	}
-	pair, ok := res["DASH_USD"]
-	if !ok {
-		err = fmt.Errorf("oh no, %s does not have DASH/USD pair", a.DisplayName())
-		return nil, err
+		return nil, badResponse(err)
 	}
-	data, err := pair.Normalize()
+	volume, err := ParseDecimal(pair.Vol)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "USD",
-		LastPrice:       data.Last,
-		BaseAssetVolume: data.BaseVolume,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              data.Last,
+		LastPriceDecimal:       last,
+		BaseAssetVolume:        data.BaseVolume,
+		BaseAssetVolumeDecimal: volume,
+		Bid:                    optionalDecimal(pair.BuyPrice),
+		Ask:                    optionalDecimal(pair.SellPrice),
+		High:                   optionalDecimal(pair.High),
+		Low:                    optionalDecimal(pair.Low),
+		QuoteAssetVolume:       optionalDecimal(pair.VolCurr),
+		FetchTime:              now,
+		ServerTime:             unixTime(int64(data.Updated)),
 	}
 
 	return &ri, nil
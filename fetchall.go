@@ -0,0 +1,211 @@
+package dashrates
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Defaults used by FetchAll for zero FetchAllOptions fields.
+const (
+	DefaultFetchWorkers = 8
+	DefaultFetchTimeout = 30 * time.Second
+)
+
+// FetchAllOptions configures FetchAll.
+type FetchAllOptions struct {
+	// Workers is the most adapters fetched at once. Zero means
+	// DefaultFetchWorkers.
+	Workers int
+
+	// Timeout bounds each adapter's fetch. Zero means DefaultFetchTimeout.
+	Timeout time.Duration
+}
+
+// Result is the outcome of fetching from one adapter. Exactly one of Rate and
+// Err is set.
+type Result struct {
+	// ID is the adapter's registry ID, if it is a DescribedAPI.
+	ID       string
+	Exchange string
+	Rate     *RateInfo
+	Err      error
+	Latency  time.Duration
+}
+
+// resultJSON is the JSON form of a Result, with the error as a string.
+type resultJSON struct {
+	ID       string    `json:",omitempty"`
+	Exchange string    `json:",omitempty"`
+	Rate     *RateInfo `json:",omitempty"`
+	Error    string    `json:",omitempty"`
+	Latency  time.Duration
+}
+
+// MarshalJSON encodes r, with Err as its message. It is part of the
+// json.Marshaler interface implementation.
+func (r Result) MarshalJSON() ([]byte, error) {
+	rj := resultJSON{
+		ID:       r.ID,
+		Exchange: r.Exchange,
+		Rate:     r.Rate,
+		Latency:  r.Latency,
+	}
+	if r.Err != nil {
+		rj.Error = r.Err.Error()
+	}
+	return json.Marshal(rj)
+}
+
+// UnmarshalJSON decodes r. The error, if any, comes back as a plain error
+// with the original message, so errors.Is no longer recognises it.
+func (r *Result) UnmarshalJSON(data []byte) error {
+	var rj resultJSON
+	if err := json.Unmarshal(data, &rj); err != nil {
+		return err
+	}
+	*r = Result{
+		ID:       rj.ID,
+		Exchange: rj.Exchange,
+		Rate:     rj.Rate,
+		Latency:  rj.Latency,
+	}
+	if rj.Error != "" {
+		r.Err = errors.New(rj.Error)
+	}
+	return nil
+}
+
+// ResultSet is a snapshot of fetching from several adapters. It is only
+// handed out once every fetch has finished, so it doesn't change afterwards
+// and can be serialized as a whole.
+type ResultSet struct {
+	// Time is when the fetches started.
+	Time time.Time
+
+	// Results has one entry per adapter, in the order they were given.
+	Results []Result
+}
+
+// Rates returns the rates which were fetched successfully.
+func (rs *ResultSet) Rates() []*RateInfo {
+	var rates []*RateInfo
+	for _, r := range rs.Results {
+		if r.Err == nil {
+			rates = append(rates, r.Rate)
+		}
+	}
+	return rates
+}
+
+// Failures returns the results for adapters whose fetch failed.
+func (rs *ResultSet) Failures() []Result {
+	var failed []Result
+	for _, r := range rs.Results {
+		if r.Err != nil {
+			failed = append(failed, r)
+		}
+	}
+	return failed
+}
+
+// MarshalBinary is part of the encoding.BinaryMarshaler interface
+func (rs *ResultSet) MarshalBinary() ([]byte, error) {
+	return json.Marshal(rs)
+}
+
+// UnmarshalBinary is part of the encoding.BinaryUnmarshaler interface
+func (rs *ResultSet) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, rs)
+}
+
+// FetchAll fetches from every API concurrently, with at most opts.Workers
+// fetches in flight and each one bounded by opts.Timeout, and returns once
+// they have all finished. A failure, or even a panic, in one adapter doesn't
+// affect the others; it is recorded in that adapter's Result.
+//
+// APIs which don't implement ContextRateAPI can't be cancelled, so when one
+// overruns its timeout FetchAll records the timeout and moves on, leaving the
+// fetch to finish in the background.
+func FetchAll(ctx context.Context, apis []RateAPI, opts FetchAllOptions) *ResultSet {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultFetchWorkers
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultFetchTimeout
+	}
+
+	rs := &ResultSet{
+		Time:    time.Now(),
+		Results: make([]Result, len(apis)),
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < len(apis); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				rs.Results[i] = fetchOne(ctx, apis[i], timeout)
+			}
+		}()
+	}
+	for i := range apis {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return rs
+}
+
+// fetchOne fetches from api with a timeout, recording the outcome.
+func fetchOne(ctx context.Context, api RateAPI, timeout time.Duration) Result {
+	r := Result{Exchange: api.DisplayName()}
+	if d, ok := api.(DescribedAPI); ok {
+		r.ID = d.Describe().ID
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type outcome struct {
+		ri  *RateInfo
+		err error
+	}
+	done := make(chan outcome, 1)
+
+	start := time.Now()
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- outcome{err: fmt.Errorf("%s: panic: %v", r.Exchange, p)}
+			}
+		}()
+		ri, err := fetchRate(ctx, api)
+		done <- outcome{ri, err}
+	}()
+
+	select {
+	case o := <-done:
+		r.Rate, r.Err = o.ri, o.err
+	case <-ctx.Done():
+		r.Err = unavailable(ctx.Err())
+	}
+	r.Latency = time.Since(start)
+
+	if r.Err == nil && r.Rate == nil {
+		r.Err = badResponse(fmt.Errorf("%s: no rate returned", r.Exchange))
+	}
+	if r.Err != nil {
+		r.Rate = nil
+	}
+
+	return r
+}
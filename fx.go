@@ -0,0 +1,188 @@
+package dashrates
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// FXSource is a source of fiat exchange rates, such as a central bank's
+// reference rates. Rates come back as RateInfos, so they can be used anywhere
+// an exchange's rates can, e.g. in a RateGraph.
+type FXSource interface {
+	DisplayName() string
+
+	// FetchFXRate gets the price of one unit of base in quote.
+	FetchFXRate(ctx context.Context, base, quote Currency) (*RateInfo, error)
+}
+
+// FXConverter wraps a RateAPI and converts its rates into another fiat
+// currency using an FXSource, e.g. Indodax's DASH/IDR into DASH/USD, or a
+// DASH/USD rate into EUR. Rates already quoted in the target currency are
+// passed through untouched.
+//
+// The converted rate keeps the FetchTime and ServerTime of the original
+// rate. The FX rate's own times, which are usually much older as reference
+// rates are published daily, are kept separately in its Conversions entry.
+type FXConverter struct {
+	API    RateAPI
+	Source FXSource
+	To     Currency
+}
+
+// NewFXConverter is a constructor for FXConverter.
+func NewFXConverter(api RateAPI, source FXSource, to Currency) *FXConverter {
+	return &FXConverter{
+		API:    api,
+		Source: source,
+		To:     to,
+	}
+}
+
+// DisplayName returns the display name of the wrapped API. It is part of the
+// RateAPI interface implementation.
+func (c *FXConverter) DisplayName() string {
+	return c.API.DisplayName()
+}
+
+// FetchRate gets the rate from the wrapped API, converting it if needed.
+//
+// This is part of the RateAPI interface implementation.
+func (c *FXConverter) FetchRate() (*RateInfo, error) {
+	return c.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the rate from the wrapped API, converting it if
+// needed, and giving up when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (c *FXConverter) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	ri, err := fetchRate(ctx, c.API)
+	if err != nil {
+		return nil, err
+	}
+	if Currency(ri.QuoteCurrency) == c.To {
+		return ri, nil
+	}
+
+	leg, err := c.Source.FetchFXRate(ctx, Currency(ri.QuoteCurrency), c.To)
+	if err != nil {
+		return nil, err
+	}
+	return convertQuote(ri, leg, c.Source.DisplayName())
+}
+
+// FXRateAPI fetches a single pair from an FXSource. It implements RateAPI,
+// so that FX rates can be cached, stored or fetched alongside exchanges.
+type FXRateAPI struct {
+	Source FXSource
+	Pair   Pair
+}
+
+// NewFXRateAPI is a constructor for FXRateAPI.
+func NewFXRateAPI(source FXSource, pair Pair) *FXRateAPI {
+	return &FXRateAPI{
+		Source: source,
+		Pair:   pair,
+	}
+}
+
+// DisplayName returns the FX source's display name. It is part of the
+// RateAPI interface implementation.
+func (a *FXRateAPI) DisplayName() string {
+	return a.Source.DisplayName()
+}
+
+// FetchRate gets the FX rate.
+//
+// This is part of the RateAPI interface implementation.
+func (a *FXRateAPI) FetchRate() (*RateInfo, error) {
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the FX rate, giving up when ctx is cancelled or its
+// deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *FXRateAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	return a.Source.FetchFXRate(ctx, a.Pair.Base, a.Pair.Quote)
+}
+
+// OpenERAPI is an FXSource using the free, keyless open access endpoint of
+// ExchangeRate-API, which covers most fiat currencies and updates daily.
+type OpenERAPI struct {
+	BaseAPIURL     string
+	LatestEndpoint string
+	Client         Doer
+}
+
+// NewOpenERAPI is a constructor for OpenERAPI.
+func NewOpenERAPI(opts ...Option) *OpenERAPI {
+	o := newOptions(opts)
+	return &OpenERAPI{
+		BaseAPIURL:     "https://open.er-api.com",
+		LatestEndpoint: "/v6/latest/",
+		Client:         o.client,
+	}
+}
+
+// DisplayName returns the FX source display name. It is part of the FXSource
+// interface implementation.
+func (a *OpenERAPI) DisplayName() string {
+	return "ExchangeRate-API"
+}
+
+// FetchFXRate gets the price of one unit of base in quote.
+//
+// This is part of the FXSource interface implementation.
+func (a *OpenERAPI) FetchFXRate(ctx context.Context, base, quote Currency) (*RateInfo, error) {
+	var res openERLatestResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.LatestEndpoint+string(base), &res)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	if res.Result != "success" {
+		e := &ExchangeError{
+			Exchange: a.DisplayName(),
+			Msg:      res.ErrorType,
+		}
+		switch res.ErrorType {
+		case "unsupported-code":
+			e.Err = ErrPairNotFound
+		case "quota-reached":
+			e.Err = ErrRateLimited
+		}
+		return nil, e
+	}
+
+	rate, ok := res.Rates[string(quote)]
+	if !ok {
+		return nil, pairNotFound(a.DisplayName(), string(base), string(quote))
+	}
+	if rate.Sign() <= 0 {
+		return nil, badResponse(fmt.Errorf("invalid %s/%s rate %q", base, quote, rate))
+	}
+
+	ri := RateInfo{
+		BaseCurrency:     string(base),
+		QuoteCurrency:    string(quote),
+		LastPrice:        rate.Float64(),
+		LastPriceDecimal: rate,
+		FetchTime:        now,
+		ServerTime:       unixTime(res.TimeLastUpdateUnix),
+	}
+
+	return &ri, nil
+}
+
+// openERLatestResp is used in parsing the ExchangeRate-API response only.
+type openERLatestResp struct {
+	Result             string             `json:"result"`
+	ErrorType          string             `json:"error-type"`
+	TimeLastUpdateUnix int64              `json:"time_last_update_unix"`
+	BaseCode           string             `json:"base_code"`
+	Rates              map[string]Decimal `json:"rates"`
+}
@@ -0,0 +1,269 @@
+package dashrates
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+)
+
+// crossRateDigits is the number of significant digits kept in derived rates.
+// Deriving a rate can involve division, which isn't exact in decimal.
+const crossRateDigits = 16
+
+// RateGraph derives rates between currencies from a set of fetched rates, by
+// chaining them together, e.g. DASH/USD from DASH/BTC and BTC/USD. Each rate
+// can be used in either direction, so BTC/USD also gives USD/BTC.
+//
+// A RateGraph isn't safe for concurrent use while rates are being added.
+type RateGraph struct {
+	edges map[Currency][]graphEdge
+}
+
+// graphEdge is a rate leading from one currency to another, possibly by
+// using a rate the other way round.
+type graphEdge struct {
+	to       Currency
+	source   string
+	rate     *RateInfo
+	inverted bool
+}
+
+// NewRateGraph is a constructor for RateGraph.
+func NewRateGraph() *RateGraph {
+	return &RateGraph{edges: make(map[Currency][]graphEdge)}
+}
+
+// Add adds a rate fetched from source, usually an exchange's display name.
+// Rates without a positive price are ignored.
+func (g *RateGraph) Add(source string, ri *RateInfo) {
+	if ri == nil || ri.LastPriceDecimal.Sign() <= 0 {
+		return
+	}
+	base, quote := Currency(ri.BaseCurrency), Currency(ri.QuoteCurrency)
+	g.edges[base] = append(g.edges[base], graphEdge{to: quote, source: source, rate: ri})
+	g.edges[quote] = append(g.edges[quote], graphEdge{to: base, source: source, rate: ri, inverted: true})
+}
+
+// AddResults adds every successfully fetched rate in rs.
+func (g *RateGraph) AddResults(rs *ResultSet) {
+	for _, r := range rs.Results {
+		if r.Err == nil {
+			g.Add(r.Exchange, r.Rate)
+		}
+	}
+}
+
+// Rate returns the rate for pair. A rate added for exactly that pair is
+// returned as is. Otherwise the rate is derived along the best path between
+// the currencies: the one with the fewest legs, then the fewest legs used
+// upside down, then the freshest oldest leg.
+//
+// A derived rate has only LastPrice set, along with FetchTime and ServerTime
+// from its oldest leg. Each leg is listed in Conversions, in order.
+func (g *RateGraph) Rate(pair Pair) (*RateInfo, error) {
+	path := g.bestPath(pair.Base, pair.Quote)
+	if path == nil {
+		return nil, &ExchangeError{
+			Exchange: "RateGraph",
+			Msg:      fmt.Sprintf("no path from %s to %s", pair.Base, pair.Quote),
+			Err:      ErrPairNotFound,
+		}
+	}
+	if len(path) == 1 && !path[0].inverted {
+		return path[0].rate, nil
+	}
+
+	// multiplying is exact, so only paths with inverted legs need rounding
+	exact := NewDecimal(1, 0)
+	price := new(big.Rat).SetInt64(1)
+	ri := &RateInfo{
+		BaseCurrency:  string(pair.Base),
+		QuoteCurrency: string(pair.Quote),
+	}
+	for i, e := range path {
+		exact = exact.Mul(e.rate.LastPriceDecimal)
+		leg := e.rate.LastPriceDecimal.Rat()
+		if e.inverted {
+			leg.Inv(leg)
+		}
+		price.Mul(price, leg)
+
+		if i == 0 || e.rate.FetchTime.Before(ri.FetchTime) {
+			ri.FetchTime = e.rate.FetchTime
+		}
+		if !e.rate.ServerTime.IsZero() && (ri.ServerTime.IsZero() || e.rate.ServerTime.Before(ri.ServerTime)) {
+			ri.ServerTime = e.rate.ServerTime
+		}
+
+		ri.Conversions = append(ri.Conversions, e.rate.Conversions...)
+		ri.Conversions = append(ri.Conversions, Conversion{
+			Source:        e.source,
+			BaseCurrency:  e.rate.BaseCurrency,
+			QuoteCurrency: e.rate.QuoteCurrency,
+			Rate:          e.rate.LastPriceDecimal,
+			Inverted:      e.inverted,
+			FetchTime:     e.rate.FetchTime,
+			ServerTime:    e.rate.ServerTime,
+		})
+	}
+	if invertedLegs(path) == 0 {
+		ri.LastPriceDecimal = exact
+	} else {
+		ri.LastPriceDecimal = roundSignificant(price, crossRateDigits)
+	}
+	ri.LastPrice = ri.LastPriceDecimal.Float64()
+
+	return ri, nil
+}
+
+// bestPath returns the best path of edges from one currency to another, or
+// nil if there is none.
+func (g *RateGraph) bestPath(from, to Currency) []graphEdge {
+	if from == to {
+		return nil
+	}
+
+	// breadth-first search for the length of the shortest path
+	depth := map[Currency]int{from: 0}
+	queue := []Currency{from}
+	for len(queue) > 0 && depth[to] == 0 {
+		c := queue[0]
+		queue = queue[1:]
+		for _, e := range g.edges[c] {
+			if _, seen := depth[e.to]; !seen {
+				depth[e.to] = depth[c] + 1
+				queue = append(queue, e.to)
+			}
+		}
+	}
+	n, ok := depth[to]
+	if !ok {
+		return nil
+	}
+
+	// then try every path of that length, keeping the best
+	var best []graphEdge
+	path := make([]graphEdge, 0, n)
+	var walk func(c Currency)
+	walk = func(c Currency) {
+		if c == to {
+			if best == nil || betterPath(path, best) {
+				best = append([]graphEdge(nil), path...)
+			}
+			return
+		}
+		for _, e := range g.edges[c] {
+			// only step one level further from the start each time
+			if d, ok := depth[e.to]; !ok || d != len(path)+1 || len(path) >= n {
+				continue
+			}
+			path = append(path, e)
+			walk(e.to)
+			path = path[:len(path)-1]
+		}
+	}
+	walk(from)
+
+	return best
+}
+
+// betterPath reports whether path a is better than path b, which is the same
+// length.
+func betterPath(a, b []graphEdge) bool {
+	if ia, ib := invertedLegs(a), invertedLegs(b); ia != ib {
+		return ia < ib
+	}
+	return oldestLeg(a).After(oldestLeg(b))
+}
+
+// invertedLegs counts the legs of path used upside down.
+func invertedLegs(path []graphEdge) int {
+	n := 0
+	for _, e := range path {
+		if e.inverted {
+			n++
+		}
+	}
+	return n
+}
+
+// oldestLeg returns the earliest FetchTime of the legs of path.
+func oldestLeg(path []graphEdge) time.Time {
+	var oldest time.Time
+	for i, e := range path {
+		if i == 0 || e.rate.FetchTime.Before(oldest) {
+			oldest = e.rate.FetchTime
+		}
+	}
+	return oldest
+}
+
+// roundSignificant rounds r to digits significant digits.
+func roundSignificant(r *big.Rat, digits int) Decimal {
+	f, _ := r.Float64()
+	if f == 0 {
+		return roundRat(r, 0)
+	}
+	magnitude := int(math.Floor(math.Log10(math.Abs(f)))) + 1
+	return roundRat(r, digits-magnitude)
+}
+
+// CrossRateAPI derives a pair from rates fetched from several APIs, e.g.
+// DASH/USD from an exchange's DASH/BTC market and another's BTC/USD market.
+// It implements RateAPI, so an exchange which only trades DASH/BTC can take
+// part in a DASH/USD Aggregator.
+type CrossRateAPI struct {
+	Name string
+	Pair Pair
+	APIs []RateAPI
+
+	// FetchOptions is passed to FetchAll.
+	FetchOptions FetchAllOptions
+}
+
+// NewCrossRateAPI is a constructor for CrossRateAPI. It takes its name from
+// api, the source of the main rate, with the other legs coming from via. For
+// example:
+//
+//	btcusd, _ := NewCoinbaseProAPIForPair("BTC", "USD")
+//	api := NewCrossRateAPI(NewPair("DASH", "USD"), NewBinanceAPI(), btcusd)
+func NewCrossRateAPI(pair Pair, api RateAPI, via ...RateAPI) *CrossRateAPI {
+	return &CrossRateAPI{
+		Name: api.DisplayName(),
+		Pair: pair,
+		APIs: append([]RateAPI{api}, via...),
+	}
+}
+
+// DisplayName returns the name of the cross rate. It is part of the RateAPI
+// interface implementation.
+func (a *CrossRateAPI) DisplayName() string {
+	return a.Name
+}
+
+// FetchRate fetches from every API and derives the rate.
+//
+// This is part of the RateAPI interface implementation.
+func (a *CrossRateAPI) FetchRate() (*RateInfo, error) {
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext fetches from every API and derives the rate, giving up
+// when ctx is cancelled or its deadline passes. Any fetch failing fails the
+// whole rate.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *CrossRateAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	rs := FetchAll(ctx, a.APIs, a.FetchOptions)
+	for _, r := range rs.Results {
+		if r.Err != nil {
+			return nil, r.Err
+		}
+	}
+
+	g := NewRateGraph()
+	g.AddResults(rs)
+	return g.Rate(a.Pair)
+}
@@ -0,0 +1,381 @@
+package dashrates
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoHistory means a HistoryStore has no rate for the time asked about.
+var ErrNoHistory = errors.New("no history")
+
+// historyExt is the extension of each series' file in a HistoryStore.
+const historyExt = ".jsonl"
+
+// HistorySeries identifies the rates for one pair from one exchange in a
+// HistoryStore.
+type HistorySeries struct {
+	Exchange string
+	Pair     Pair
+}
+
+// HistoryStore keeps every rate it is given in files under a directory, so
+// that past rates can be looked up later without running a database server.
+// Rates are keyed by exchange, pair and FetchTime.
+//
+// Each series is a file of JSON encoded RateInfos, one per line, which rates
+// are appended to as they are added. A series is read into memory the first
+// time it is used. Rates can be added out of order.
+//
+// A HistoryStore is safe for concurrent use, but only one should use a
+// directory at a time.
+type HistoryStore struct {
+	dir string
+
+	mu     sync.Mutex
+	series map[HistorySeries][]*RateInfo
+}
+
+// OpenHistoryStore opens the HistoryStore in dir, creating the directory if
+// needed.
+func OpenHistoryStore(dir string) (*HistoryStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &HistoryStore{
+		dir:    dir,
+		series: make(map[HistorySeries][]*RateInfo),
+	}, nil
+}
+
+// Add stores ri under exchange, which is usually its registry ID. It must
+// have a FetchTime and LastPriceDecimal.
+func (s *HistoryStore) Add(exchange string, ri *RateInfo) error {
+	if ri == nil {
+		return fmt.Errorf("nil rate for %s", exchange)
+	}
+	if ri.FetchTime.IsZero() {
+		return fmt.Errorf("%s rate has no FetchTime", exchange)
+	}
+	if !ri.LastPriceDecimal.IsSet() {
+		return fmt.Errorf("%s rate has no LastPriceDecimal", exchange)
+	}
+	key := HistorySeries{
+		Exchange: exchange,
+		Pair:     NewPair(ri.BaseCurrency, ri.QuoteCurrency),
+	}
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rates, err := s.load(key)
+	if err != nil {
+		return err
+	}
+
+	data, err := ri.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+
+	// keep the series sorted, after any rates fetched at the same time
+	i := sort.Search(len(rates), func(i int) bool {
+		return rates[i].FetchTime.After(ri.FetchTime)
+	})
+	rates = append(rates, nil)
+	copy(rates[i+1:], rates[i:])
+	rates[i] = copyRate(ri)
+	s.series[key] = rates
+
+	return nil
+}
+
+// AddResults stores every successfully fetched rate in rs, under each
+// result's ID, or its exchange name if it has none.
+func (s *HistoryStore) AddResults(rs *ResultSet) error {
+	for _, r := range rs.Results {
+		if r.Err != nil || r.Rate == nil {
+			continue
+		}
+		exchange := r.ID
+		if exchange == "" {
+			exchange = r.Exchange
+		}
+		if err := s.Add(exchange, r.Rate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Range returns the rates for exchange and pair fetched from start up to but
+// not including end, oldest first. A zero end leaves the range open.
+func (s *HistoryStore) Range(exchange string, pair Pair, start, end time.Time) ([]*RateInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rates, err := s.load(HistorySeries{Exchange: exchange, Pair: pair})
+	if err != nil {
+		return nil, err
+	}
+
+	i := sort.Search(len(rates), func(i int) bool {
+		return !rates[i].FetchTime.Before(start)
+	})
+	j := len(rates)
+	if !end.IsZero() {
+		j = sort.Search(len(rates), func(i int) bool {
+			return !rates[i].FetchTime.Before(end)
+		})
+	}
+
+	var out []*RateInfo
+	for ; i < j; i++ {
+		out = append(out, copyRate(rates[i]))
+	}
+	return out, nil
+}
+
+// LatestBefore returns the last rate for exchange and pair fetched at or
+// before t, or ErrNoHistory if there isn't one.
+func (s *HistoryStore) LatestBefore(exchange string, pair Pair, t time.Time) (*RateInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rates, err := s.load(HistorySeries{Exchange: exchange, Pair: pair})
+	if err != nil {
+		return nil, err
+	}
+
+	i := sort.Search(len(rates), func(i int) bool {
+		return rates[i].FetchTime.After(t)
+	})
+	if i == 0 {
+		return nil, fmt.Errorf("%s %s at %s: %w", exchange, pair, t.Format(time.RFC3339), ErrNoHistory)
+	}
+	return copyRate(rates[i-1]), nil
+}
+
+// around returns the last rate for exchange and pair fetched at or before t,
+// and the first fetched after it. Either is nil if there is no such rate.
+func (s *HistoryStore) around(exchange string, pair Pair, t time.Time) (before, after *RateInfo, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rates, err := s.load(HistorySeries{Exchange: exchange, Pair: pair})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	i := sort.Search(len(rates), func(i int) bool {
+		return rates[i].FetchTime.After(t)
+	})
+	if i > 0 {
+		before = copyRate(rates[i-1])
+	}
+	if i < len(rates) {
+		after = copyRate(rates[i])
+	}
+	return before, after, nil
+}
+
+// Series lists every series in the store, sorted by exchange and pair.
+func (s *HistoryStore) Series() ([]HistorySeries, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list()
+}
+
+// DeleteBefore deletes every rate fetched before cutoff, returning how many
+// were deleted. To keep a retention window, call it periodically with e.g.
+// time.Now().Add(-30 * 24 * time.Hour).
+func (s *HistoryStore) DeleteBefore(cutoff time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all, err := s.list()
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, key := range all {
+		rates, err := s.load(key)
+		if err != nil {
+			return deleted, err
+		}
+		i := sort.Search(len(rates), func(i int) bool {
+			return !rates[i].FetchTime.Before(cutoff)
+		})
+		if i == 0 {
+			continue
+		}
+		if err := s.rewrite(key, rates[i:]); err != nil {
+			return deleted, err
+		}
+		s.series[key] = rates[i:]
+		deleted += i
+	}
+	return deleted, nil
+}
+
+// list finds every series file under the store's directory.
+func (s *HistoryStore) list() ([]HistorySeries, error) {
+	dirs, err := ioutil.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []HistorySeries
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		exchange, err := url.PathUnescape(d.Name())
+		if err != nil {
+			continue
+		}
+		files, err := ioutil.ReadDir(filepath.Join(s.dir, d.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			name := f.Name()
+			if f.IsDir() || !strings.HasSuffix(name, historyExt) {
+				continue
+			}
+			symbol, err := url.PathUnescape(strings.TrimSuffix(name, historyExt))
+			if err != nil {
+				continue
+			}
+			pair, err := ParsePair(symbol)
+			if err != nil {
+				continue
+			}
+			all = append(all, HistorySeries{Exchange: exchange, Pair: pair})
+		}
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].Exchange != all[j].Exchange {
+			return all[i].Exchange < all[j].Exchange
+		}
+		return all[i].Pair.String() < all[j].Pair.String()
+	})
+	return all, nil
+}
+
+// load returns the rates in a series, sorted by FetchTime, reading them from
+// its file the first time. The caller must hold s.mu.
+func (s *HistoryStore) load(key HistorySeries) ([]*RateInfo, error) {
+	if rates, ok := s.series[key]; ok {
+		return rates, nil
+	}
+	path, err := s.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rates []*RateInfo
+	lines := bytes.Split(data, []byte("\n"))
+	for n, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var ri RateInfo
+		if err := ri.UnmarshalBinary(line); err != nil {
+			// a crash can leave the last line partly written, which is
+			// dropped so that rates can be appended after it again
+			if n == len(lines)-1 {
+				if err := os.Truncate(path, int64(len(data)-len(line))); err != nil {
+					return nil, err
+				}
+				break
+			}
+			return nil, fmt.Errorf("%s:%d: %v", path, n+1, err)
+		}
+		rates = append(rates, &ri)
+	}
+
+	sort.SliceStable(rates, func(i, j int) bool {
+		return rates[i].FetchTime.Before(rates[j].FetchTime)
+	})
+	s.series[key] = rates
+	return rates, nil
+}
+
+// rewrite replaces a series' file with rates, or removes it if there are
+// none. The new file is written alongside and renamed into place, so a crash
+// leaves either the old or new file intact.
+func (s *HistoryStore) rewrite(key HistorySeries, rates []*RateInfo) error {
+	path, err := s.path(key)
+	if err != nil {
+		return err
+	}
+	if len(rates) == 0 {
+		return os.Remove(path)
+	}
+
+	var buf bytes.Buffer
+	for _, ri := range rates {
+		data, err := ri.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// path returns the file a series is stored in, which is named after the
+// pair in a directory named after the exchange.
+func (s *HistoryStore) path(key HistorySeries) (string, error) {
+	if key.Exchange == "" || key.Exchange == "." || key.Exchange == ".." {
+		return "", fmt.Errorf("invalid exchange name %q", key.Exchange)
+	}
+	if key.Pair.Base == "" || key.Pair.Quote == "" {
+		return "", fmt.Errorf("invalid pair %q", key.Pair)
+	}
+	return filepath.Join(s.dir, url.PathEscape(key.Exchange),
+		url.PathEscape(key.Pair.String())+historyExt), nil
+}
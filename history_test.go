@@ -0,0 +1,274 @@
+package dashrates
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var historyStart = time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+
+// historyRate is a DASH/USD rate fetched minute minutes after historyStart.
+func historyRate(minute int, price string) *RateInfo {
+	d, _ := ParseDecimal(price)
+	return &RateInfo{
+		BaseCurrency:     "DASH",
+		QuoteCurrency:    "USD",
+		LastPrice:        d.Float64(),
+		LastPriceDecimal: d,
+		FetchTime:        historyStart.Add(time.Duration(minute) * time.Minute),
+	}
+}
+
+func historyPrices(rates []*RateInfo) string {
+	prices := make([]string, len(rates))
+	for i, ri := range rates {
+		prices[i] = ri.LastPriceDecimal.String()
+	}
+	return strings.Join(prices, " ")
+}
+
+func openTestHistory(t *testing.T, dir string) *HistoryStore {
+	t.Helper()
+	s, err := OpenHistoryStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func addHistory(t *testing.T, s *HistoryStore, rates ...*RateInfo) {
+	t.Helper()
+	for _, ri := range rates {
+		if err := s.Add("kraken", ri); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestHistoryStoreRange(t *testing.T) {
+	s := openTestHistory(t, t.TempDir())
+	// added out of order, with two rates fetched at the same time
+	addHistory(t, s, historyRate(2, "2"), historyRate(0, "0"), historyRate(3, "3"), historyRate(1, "1"), historyRate(2, "2.5"))
+
+	dashUSD := NewPair("DASH", "USD")
+	at := func(minute int) time.Time { return historyStart.Add(time.Duration(minute) * time.Minute) }
+	tests := []struct {
+		name       string
+		start, end time.Time
+		want       string
+	}{
+		{"all", time.Time{}, time.Time{}, "0 1 2 2.5 3"},
+		{"start is inclusive", at(1), time.Time{}, "1 2 2.5 3"},
+		{"end is exclusive", at(1), at(3), "1 2 2.5"},
+		{"between samples", at(1).Add(time.Second), at(2).Add(time.Second), "2 2.5"},
+		{"empty", at(4), time.Time{}, ""},
+	}
+	for _, tt := range tests {
+		rates, err := s.Range("kraken", dashUSD, tt.start, tt.end)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := historyPrices(rates); got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	// the caller's copies don't change the store
+	rates, _ := s.Range("kraken", dashUSD, time.Time{}, time.Time{})
+	rates[0].LastPriceDecimal = NewDecimal(99, 0)
+	if rates, _ := s.Range("kraken", dashUSD, time.Time{}, time.Time{}); historyPrices(rates[:1]) != "0" {
+		t.Error("changing a returned rate changed the store")
+	}
+
+	if rates, err := s.Range("kraken", NewPair("DASH", "BTC"), time.Time{}, time.Time{}); err != nil || len(rates) != 0 {
+		t.Errorf("unknown series: got %d rates, %v", len(rates), err)
+	}
+}
+
+func TestHistoryStoreLatestBefore(t *testing.T) {
+	s := openTestHistory(t, t.TempDir())
+	addHistory(t, s, historyRate(10, "10"), historyRate(0, "0"), historyRate(5, "5"))
+
+	dashUSD := NewPair("DASH", "USD")
+	tests := []struct {
+		minute int
+		want   string
+	}{
+		{0, "0"},
+		{4, "0"},
+		{5, "5"},
+		{60, "10"},
+	}
+	for _, tt := range tests {
+		ri, err := s.LatestBefore("kraken", dashUSD, historyStart.Add(time.Duration(tt.minute)*time.Minute))
+		if err != nil {
+			t.Errorf("minute %d: %v", tt.minute, err)
+			continue
+		}
+		if got := ri.LastPriceDecimal.String(); got != tt.want {
+			t.Errorf("minute %d: got %s, want %s", tt.minute, got, tt.want)
+		}
+	}
+
+	if _, err := s.LatestBefore("kraken", dashUSD, historyStart.Add(-time.Second)); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("before the first rate: got %v, want ErrNoHistory", err)
+	}
+}
+
+func TestHistoryStoreAddInvalid(t *testing.T) {
+	s := openTestHistory(t, t.TempDir())
+
+	noTime := historyRate(0, "1")
+	noTime.FetchTime = time.Time{}
+	noPrice := historyRate(0, "1")
+	noPrice.LastPriceDecimal = Decimal{}
+	for _, tt := range []struct {
+		name     string
+		exchange string
+		ri       *RateInfo
+	}{
+		{"nil", "kraken", nil},
+		{"no FetchTime", "kraken", noTime},
+		{"no price", "kraken", noPrice},
+		{"bad exchange", "..", historyRate(0, "1")},
+		{"no exchange", "", historyRate(0, "1")},
+	} {
+		if err := s.Add(tt.exchange, tt.ri); err == nil {
+			t.Errorf("%s: no error", tt.name)
+		}
+	}
+}
+
+func TestHistoryStoreReopen(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestHistory(t, dir)
+	addHistory(t, s, historyRate(1, "1.10"), historyRate(0, "1.00"))
+	if err := s.Add("Exchange/Name", historyRate(0, "2")); err != nil {
+		t.Fatal(err)
+	}
+
+	s = openTestHistory(t, dir)
+	series, err := s.Series()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(series) != 2 || series[0].Exchange != "Exchange/Name" || series[1].Exchange != "kraken" {
+		t.Fatalf("got series %v", series)
+	}
+	rates, err := s.Range("kraken", NewPair("DASH", "USD"), time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sorted on reading, with trailing zeros kept
+	if got := historyPrices(rates); got != "1.00 1.10" {
+		t.Errorf("got %q after reopening", got)
+	}
+	if !rates[0].FetchTime.Equal(historyStart) {
+		t.Errorf("FetchTime: got %s, want %s", rates[0].FetchTime, historyStart)
+	}
+}
+
+func TestHistoryStoreTornWrite(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestHistory(t, dir)
+	addHistory(t, s, historyRate(0, "1"), historyRate(1, "2"))
+
+	// a crash partway through appending the third rate
+	path := filepath.Join(dir, "kraken", "DASH%2FUSD"+historyExt)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"BaseCurrency":"DASH","Quo`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	s = openTestHistory(t, dir)
+	rates, err := s.Range("kraken", NewPair("DASH", "USD"), time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := historyPrices(rates); got != "1 2" {
+		t.Errorf("got %q, want the rates before the torn line", got)
+	}
+
+	// the torn line is dropped from the file, so appending works again
+	addHistory(t, s, historyRate(2, "3"))
+	s = openTestHistory(t, dir)
+	rates, err = s.Range("kraken", NewPair("DASH", "USD"), time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := historyPrices(rates); got != "1 2 3" {
+		t.Errorf("after appending: got %q", got)
+	}
+
+	// a bad line which isn't the last is an error rather than data loss
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, append([]byte("not json\n"), data...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s = openTestHistory(t, dir)
+	if _, err := s.Range("kraken", NewPair("DASH", "USD"), time.Time{}, time.Time{}); err == nil {
+		t.Error("no error reading a corrupt line")
+	}
+}
+
+func TestHistoryStoreDeleteBefore(t *testing.T) {
+	dir := t.TempDir()
+	s := openTestHistory(t, dir)
+	addHistory(t, s, historyRate(0, "0"), historyRate(1, "1"), historyRate(2, "2"))
+	if err := s.Add("bitfinex", historyRate(0, "5")); err != nil {
+		t.Fatal(err)
+	}
+
+	n, err := s.DeleteBefore(historyStart.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("deleted %d rates, want 2", n)
+	}
+
+	// the rewritten files replace the old ones, with nothing left behind
+	check := func(what string, s *HistoryStore) {
+		t.Helper()
+		rates, err := s.Range("kraken", NewPair("DASH", "USD"), time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := historyPrices(rates); got != "1 2" {
+			t.Errorf("%s: got %q, want %q", what, got, "1 2")
+		}
+		series, err := s.Series()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(series) != 1 || series[0].Exchange != "kraken" {
+			t.Errorf("%s: got series %v, want only kraken's", what, series)
+		}
+	}
+	check("after deleting", s)
+	check("after reopening", openTestHistory(t, dir))
+
+	tmps, err := filepath.Glob(filepath.Join(dir, "*", "*.tmp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tmps) != 0 {
+		t.Errorf("temporary files left behind: %v", tmps)
+	}
+
+	if n, err := s.DeleteBefore(historyStart); err != nil || n != 0 {
+		t.Errorf("deleting nothing: got %d, %v", n, err)
+	}
+}
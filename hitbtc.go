@@ -1,9 +1,7 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
 	"strconv"
 	"time"
 )
@@ -13,57 +11,113 @@ import (
 type HitBTCAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("hitbtc", func(opts ...Option) RateAPI { return NewHitBTCAPI(opts...) })
 }
 
 // NewHitBTCAPI is a constructor for HitBTCAPI.
-func NewHitBTCAPI() *HitBTCAPI {
+func NewHitBTCAPI(opts ...Option) *HitBTCAPI {
+	a, _ := NewHitBTCAPIForPair("DASH", "USD", opts...)
+	return a
+}
+
+// NewHitBTCAPIForPair is a constructor for a HitBTCAPI which fetches the rate
+// for the base/quote pair, e.g. ("DASH", "USD"). It returns an error wrapping
+// ErrPairNotFound for pairs not in hitBTCPairs.
+func NewHitBTCAPIForPair(base, quote string, opts ...Option) (*HitBTCAPI, error) {
+	base, quote, err := checkPair("HitBTC", hitBTCPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &HitBTCAPI{
 		BaseAPIURL:          "https://api.hitbtc.com",
-		PriceTickerEndpoint: "/api/2/public/ticker/DASHUSD",
-	}
+		PriceTickerEndpoint: "/api/2/public/ticker/" + exchangeSymbol("hitbtc", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// hitBTCPairs lists the HitBTC markets which NewHitBTCAPIForPair accepts.
+var hitBTCPairs = []string{"DASH/USD", "DASH/BTC", "DASH/ETH"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *HitBTCAPI) DisplayName() string {
 	return "HitBTC"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *HitBTCAPI) Describe() Description {
+	return Description{
+		ID:        "hitbtc",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(hitBTCPairs),
+		Price:     LastTradePrice,
+		HasVolume: true,
+		URL:       "https://hitbtc.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the HitBTC API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *HitBTCAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the HitBTC API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *HitBTCAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res hitBTCPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	data, err := res.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
-	// parse json and extract Dash rate
-	var res hitBTCPubTickerResp
-	err = json.Unmarshal(body, &res)
+	last, err := ParseDecimal(res.Last)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
-
-	data, err := res.Normalize()
+	volume, err := ParseDecimal(res.BaseVol)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "USD",
-		LastPrice:       data.Last,
-		BaseAssetVolume: data.BaseVolume,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              data.Last,
+		LastPriceDecimal:       last,
+		BaseAssetVolume:        data.BaseVolume,
+		BaseAssetVolumeDecimal: volume,
+		Bid:                    optionalDecimal(res.Bid),
+		Ask:                    optionalDecimal(res.Ask),
+		High:                   optionalDecimal(res.High),
+		Low:                    optionalDecimal(res.Low),
+		Open:                   optionalDecimal(res.Open),
+		QuoteAssetVolume:       optionalDecimal(res.QuoteVol),
+		FetchTime:              now,
+		ServerTime:             data.Timestamp,
 	}
 
 	return &ri, nil
@@ -0,0 +1,152 @@
+package dashrates
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// Doer is the part of *http.Client the adapters use to send requests. Any
+// *http.Client satisfies it, as does a wrapper which adds headers, retries or
+// a fake transport for testing.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DefaultClient is used by every adapter which wasn't given a client of its
+// own. Replace it to change timeouts, proxies, TLS settings or the User-Agent
+// for the whole package at once.
+var DefaultClient Doer = http.DefaultClient
+
+// Option configures an adapter when passed to its constructor.
+type Option func(*options)
+
+// options holds the settings collected from a list of Option values.
+type options struct {
+	client Doer
+}
+
+// WithHTTPClient makes an adapter send all of its requests through c instead
+// of DefaultClient.
+func WithHTTPClient(c Doer) Option {
+	return func(o *options) {
+		o.client = c
+	}
+}
+
+// newOptions applies opts in order and returns the result.
+func newOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// httpGet issues a GET request for url which is cancelled along with ctx. The
+// request is sent through client, or DefaultClient if client is nil.
+func httpGet(ctx context.Context, client Doer, url string) (*http.Response, error) {
+	if client == nil {
+		client = DefaultClient
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(req)
+}
+
+// MaxResponseSize is the largest response body, in bytes, that an adapter will
+// read. Anything bigger is rejected rather than buffered in memory.
+var MaxResponseSize int64 = 8 << 20
+
+// errorBodyLimit is how much of a failed response's body is kept in an
+// HTTPError.
+const errorBodyLimit = 512
+
+// HTTPError is returned when an exchange API answers with a non-2xx status,
+// e.g. a 429 when rate limited or a 503 HTML page during maintenance.
+type HTTPError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	Header     http.Header
+
+	// Body holds at most the first 512 bytes of the response body.
+	Body []byte
+}
+
+// Error is part of the error interface.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("GET %s: unexpected status %s: %q", e.URL, e.Status, e.Body)
+}
+
+// getJSON fetches url through client and decodes the JSON response into v.
+//
+// Non-2xx responses are returned as an *HTTPError without attempting to
+// decode them, and bodies larger than MaxResponseSize are rejected. Other
+// failures are classified as ErrExchangeUnavailable or ErrBadResponse.
+func getJSON(ctx context.Context, client Doer, url string, v interface{}) error {
+	body, err := getBody(ctx, client, url)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(body, v)
+	if err != nil {
+		return badResponse(err)
+	}
+
+	return nil
+}
+
+// getXML is like getJSON, but for XML responses.
+func getXML(ctx context.Context, client Doer, url string, v interface{}) error {
+	body, err := getBody(ctx, client, url)
+	if err != nil {
+		return err
+	}
+
+	err = xml.Unmarshal(body, v)
+	if err != nil {
+		return badResponse(err)
+	}
+
+	return nil
+}
+
+// getBody fetches url through client and returns the response body, with
+// errors handled as described for getJSON.
+func getBody(ctx context.Context, client Doer, url string) ([]byte, error) {
+	resp, err := httpGet(ctx, client, url)
+	if err != nil {
+		return nil, unavailable(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, errorBodyLimit))
+		return nil, &HTTPError{
+			URL:        url,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			Header:     resp.Header,
+			Body:       body,
+		}
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, MaxResponseSize+1))
+	if err != nil {
+		return nil, unavailable(err)
+	}
+	if int64(len(body)) > MaxResponseSize {
+		err = fmt.Errorf("GET %s: response body exceeds %d bytes", url, MaxResponseSize)
+		return nil, badResponse(err)
+	}
+
+	return body, nil
+}
@@ -1,9 +1,9 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
+	"errors"
+	"strings"
 	"time"
 )
 
@@ -13,54 +13,125 @@ type HuobiAPI struct {
 	BaseAPIURL           string
 	MarketDetailEndpoint string
 	LastTradeEndpoint    string
+	BaseCurrency         string
+	QuoteCurrency        string
+	Client               Doer
+}
+
+func init() {
+	Register("huobi", func(opts ...Option) RateAPI { return NewHuobiAPI(opts...) })
 }
 
 // NewHuobiAPI is a constructor for HuobiAPI.
-func NewHuobiAPI() *HuobiAPI {
+func NewHuobiAPI(opts ...Option) *HuobiAPI {
+	a, _ := NewHuobiAPIForPair("DASH", "BTC", opts...)
+	return a
+}
+
+// NewHuobiAPIForPair is a constructor for a HuobiAPI which fetches the rate for
+// the base/quote pair, e.g. ("DASH", "BTC"). It returns an error wrapping
+// ErrPairNotFound for pairs not in huobiPairs.
+func NewHuobiAPIForPair(base, quote string, opts ...Option) (*HuobiAPI, error) {
+	base, quote, err := checkPair("Huobi", huobiPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &HuobiAPI{
 		BaseAPIURL:           "https://api.huobi.pro",
-		MarketDetailEndpoint: "/market/detail/merged?symbol=dashbtc",
-		LastTradeEndpoint:    "/market/trade?symbol=dashbtc",
-	}
+		MarketDetailEndpoint: "/market/detail/merged?symbol=" + exchangeSymbol("huobi", base, quote),
+		LastTradeEndpoint:    "/market/trade?symbol=" + exchangeSymbol("huobi", base, quote),
+		BaseCurrency:         base,
+		QuoteCurrency:        quote,
+		Client:               o.client,
+	}, nil
 }
 
+// huobiPairs lists the Huobi markets which NewHuobiAPIForPair accepts.
+var huobiPairs = []string{"DASH/BTC", "DASH/USDT"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *HuobiAPI) DisplayName() string {
 	return "Huobi"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *HuobiAPI) Describe() Description {
+	return Description{
+		ID:    "huobi",
+		Name:  a.DisplayName(),
+		Pair:  NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs: parsePairs(huobiPairs),
+		Price: LastTradePrice,
+		URL:   "https://www.huobi.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Huobi API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *HuobiAPI) FetchRate() (*RateInfo, error) {
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the Huobi API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *HuobiAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
 	now := time.Now()
 
 	// parse json and extract Dash rate
-	//marketDetail, err := a.fetchMarketDetail()
+	//marketDetail, err := a.fetchMarketDetail(ctx)
 	//if err != nil {
 	//	return nil, err
 	//}
-	lastTradePrice, err := a.fetchLastTrade()
+	lastTradePrice, serverTime, err := a.fetchLastTrade(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	ri := RateInfo{
-		BaseCurrency:  "DASH",
-		QuoteCurrency: "BTC",
-		LastPrice:     lastTradePrice,
+		BaseCurrency:     a.BaseCurrency,
+		QuoteCurrency:    a.QuoteCurrency,
+		LastPrice:        lastTradePrice.Float64(),
+		LastPriceDecimal: lastTradePrice,
 		//BaseAssetVolume: marketDetail.Tick.Volume,
 		BaseAssetVolume: 0,
 		FetchTime:       now,
+		ServerTime:      serverTime,
 	}
 
 	return &ri, nil
 }
 
+// huobiStatusOK is the status Huobi sends with every successful response.
+const huobiStatusOK = "ok"
+
+// huobiError converts the error fields of a Huobi response into an
+// *ExchangeError.
+func huobiError(exchange, code, msg string) error {
+	var class error
+	if code == "invalid-parameter" && strings.Contains(msg, "symbol") {
+		class = ErrPairNotFound
+	}
+
+	return &ExchangeError{
+		Exchange: exchange,
+		Code:     code,
+		Msg:      msg,
+		Err:      class,
+	}
+}
+
 // huobiPubTickerResp is used in parsing the Huobi API response only.
 type huobiPubTickerResp struct {
 	Status    string `json:"status"`
+	ErrCode   string `json:"err-code"`
+	ErrMsg    string `json:"err-msg"`
 	Channel   string `json:"ch"`
 	Timestamp int64  `json:"ts"`
 	Tick      struct {
@@ -81,6 +152,8 @@ type huobiPubTickerResp struct {
 // huobiLastTradeResp is used in parsing the Huobi API response only.
 type huobiLastTradeResp struct {
 	Status    string `json:"status"`
+	ErrCode   string `json:"err-code"`
+	ErrMsg    string `json:"err-msg"`
 	Channel   string `json:"ch"`
 	Timestamp int64  `json:"ts"`
 	Tick      struct {
@@ -90,54 +163,54 @@ type huobiLastTradeResp struct {
 			Amount    float64 `json:"amount"`
 			Timestamp int64   `json:"ts"`
 			// ID        int64   `json:"id"`
-			Price     float64 `json:"price"`
+			Price     Decimal `json:"price"`
 			Direction string  `json:"direction"`
 		} `json:"data"`
 	} `json:"tick"`
 }
 
-// fetchLastTrade gets the Dash exchange rate from the Huobi API.
-func (a *HuobiAPI) fetchLastTrade() (float64, error) {
+// fetchLastTrade gets the Dash exchange rate from the Huobi API, along with
+// the time of the trade.
+func (a *HuobiAPI) fetchLastTrade(ctx context.Context) (Decimal, time.Time, error) {
 	// Get last trade
-	resp, err := http.Get(a.BaseAPIURL + a.LastTradeEndpoint)
-	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return 0, err
-	}
-
 	// parse json and extract Dash rate
 	var res huobiLastTradeResp
-	err = json.Unmarshal(body, &res)
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.LastTradeEndpoint, &res)
 	if err != nil {
-		return 0, err
+		return Decimal{}, time.Time{}, err
+	}
+	if res.Status != huobiStatusOK {
+		return Decimal{}, time.Time{}, huobiError(a.DisplayName(), res.ErrCode, res.ErrMsg)
 	}
 
-	return res.Tick.Data[0].Price, nil
-}
+	if len(res.Tick.Data) == 0 {
+		return Decimal{}, time.Time{}, badResponse(errors.New("no trades in response"))
+	}
 
-// fetchMarketDetail gets the Dash market detail from the Huobi API.
-func (a *HuobiAPI) fetchMarketDetail() (*huobiPubTickerResp, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.MarketDetailEndpoint)
-	if err != nil {
-		return nil, err
+	if !res.Tick.Data[0].Price.IsSet() {
+		return Decimal{}, time.Time{}, badResponse(errors.New("no price in response"))
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	// the response's own ts is when it was generated, which is always now
+	trade := res.Tick.Data[0]
+	tradeTime := trade.Timestamp
+	if tradeTime == 0 {
+		tradeTime = res.Tick.Timestamp
 	}
 
+	return trade.Price, unixMilliTime(tradeTime), nil
+}
+
+// fetchMarketDetail gets the Dash market detail from the Huobi API.
+func (a *HuobiAPI) fetchMarketDetail(ctx context.Context) (*huobiPubTickerResp, error) {
 	var res huobiPubTickerResp
-	err = json.Unmarshal(body, &res)
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.MarketDetailEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
+	if res.Status != huobiStatusOK {
+		return nil, huobiError(a.DisplayName(), res.ErrCode, res.ErrMsg)
+	}
 
 	return &res, nil
 }
@@ -1,10 +1,10 @@
 package dashrates
 
 import (
+	"context"
 	"encoding/json"
-	"io/ioutil"
-	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,57 +13,115 @@ import (
 type IndodaxAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("indodax", func(opts ...Option) RateAPI { return NewIndodaxAPI(opts...) })
 }
 
 // NewIndodaxAPI is a constructor for IndodaxAPI.
-func NewIndodaxAPI() *IndodaxAPI {
+func NewIndodaxAPI(opts ...Option) *IndodaxAPI {
+	a, _ := NewIndodaxAPIForPair("DASH", "BTC", opts...)
+	return a
+}
+
+// NewIndodaxAPIForPair is a constructor for an IndodaxAPI which fetches the
+// rate for the base/quote pair, e.g. ("DASH", "BTC"). It returns an error
+// wrapping ErrPairNotFound for pairs not in indodaxPairs.
+func NewIndodaxAPIForPair(base, quote string, opts ...Option) (*IndodaxAPI, error) {
+	base, quote, err := checkPair("Indodax", indodaxPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &IndodaxAPI{
 		BaseAPIURL:          "https://indodax.com",
-		PriceTickerEndpoint: "/api/drk_btc/ticker",
-	}
+		PriceTickerEndpoint: "/api/" + exchangeSymbol("indodax", base, quote) + "/ticker",
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// indodaxPairs lists the Indodax markets which NewIndodaxAPIForPair accepts.
+var indodaxPairs = []string{"DASH/BTC", "DASH/IDR"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *IndodaxAPI) DisplayName() string {
 	return "Indodax"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *IndodaxAPI) Describe() Description {
+	return Description{
+		ID:        "indodax",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(indodaxPairs),
+		Price:     LastTradePrice,
+		HasVolume: true,
+		URL:       "https://indodax.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Indodax API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *IndodaxAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the Indodax API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *IndodaxAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res indodaxPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	baseVol := res.Ticker.Volumes[exchangeCurrency("indodax", a.BaseCurrency)]
+	quoteVol := res.Ticker.Volumes[exchangeCurrency("indodax", a.QuoteCurrency)]
+
+	data, err := res.Normalize(baseVol, quoteVol)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
-	// parse json and extract Dash rate
-	var res indodaxPubTickerResp
-	err = json.Unmarshal(body, &res)
+	last, err := ParseDecimal(res.Ticker.Last)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
-
-	data, err := res.Normalize()
+	volume, err := ParseDecimal(baseVol)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "BTC",
-		LastPrice:       data.Last,
-		BaseAssetVolume: data.VolDrk,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              data.Last,
+		LastPriceDecimal:       last,
+		BaseAssetVolume:        data.VolBase,
+		BaseAssetVolumeDecimal: volume,
+		Bid:                    optionalDecimal(res.Ticker.Buy),
+		Ask:                    optionalDecimal(res.Ticker.Sell),
+		High:                   optionalDecimal(res.Ticker.High),
+		Low:                    optionalDecimal(res.Ticker.Low),
+		QuoteAssetVolume:       optionalDecimal(quoteVol),
+		FetchTime:              now,
+		ServerTime:             data.ServerTime,
 	}
 
 	return &ri, nil
@@ -71,33 +129,64 @@ func (a *IndodaxAPI) FetchRate() (*RateInfo, error) {
 
 // indodaxPubTickerResp is used in parsing the Indodax API response only.
 type indodaxPubTickerResp struct {
-	Ticker struct {
-		High       string `json:"high"`
-		Low        string `json:"low"`
-		VolDrk     string `json:"vol_drk"`
-		VolBtc     string `json:"vol_btc"`
-		Last       string `json:"last"`
-		Buy        string `json:"buy"`
-		Sell       string `json:"sell"`
-		ServerTime int64  `json:"server_time"`
+	Ticker indodaxTicker
+}
+
+// indodaxTicker is used in parsing the Indodax API response only. Indodax
+// names the volume fields after the currencies, e.g. vol_drk and vol_btc, so
+// these are collected into Volumes, keyed by Indodax's currency name.
+type indodaxTicker struct {
+	High       string            `json:"high"`
+	Low        string            `json:"low"`
+	Last       string            `json:"last"`
+	Buy        string            `json:"buy"`
+	Sell       string            `json:"sell"`
+	ServerTime int64             `json:"server_time"`
+	Volumes    map[string]string `json:"-"`
+}
+
+// UnmarshalJSON decodes the ticker, gathering the vol_ fields into Volumes.
+func (t *indodaxTicker) UnmarshalJSON(data []byte) error {
+	type ticker indodaxTicker
+	if err := json.Unmarshal(data, (*ticker)(t)); err != nil {
+		return err
 	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	t.Volumes = make(map[string]string)
+	for k, v := range fields {
+		if !strings.HasPrefix(k, "vol_") {
+			continue
+		}
+		var vol string
+		if err := json.Unmarshal(v, &vol); err != nil {
+			return err
+		}
+		t.Volumes[strings.TrimPrefix(k, "vol_")] = vol
+	}
+
+	return nil
 }
 
 // indodaxPubTickerData is used in parsing the Indodax API response only.
 type indodaxPubTickerData struct {
 	High       float64
 	Low        float64
-	VolDrk     float64
-	VolBtc     float64
+	VolBase    float64
+	VolQuote   float64
 	Last       float64
 	Buy        float64
 	Sell       float64
 	ServerTime time.Time
 }
 
-// Normalize parses the fields in indodaxPubTickerResp and returns a
+// Normalize parses the fields in indodaxPubTickerResp, along with the base and
+// quote volumes picked out of Ticker.Volumes, and returns a
 // indodaxPubTickerData with proper data types.
-func (resp *indodaxPubTickerResp) Normalize() (*indodaxPubTickerData, error) {
+func (resp *indodaxPubTickerResp) Normalize(baseVol, quoteVol string) (*indodaxPubTickerData, error) {
 	high, err := strconv.ParseFloat(resp.Ticker.High, 64)
 	if err != nil {
 		return nil, err
@@ -108,12 +197,12 @@ func (resp *indodaxPubTickerResp) Normalize() (*indodaxPubTickerData, error) {
 		return nil, err
 	}
 
-	volDrk, err := strconv.ParseFloat(resp.Ticker.VolDrk, 64)
+	volBase, err := strconv.ParseFloat(baseVol, 64)
 	if err != nil {
 		return nil, err
 	}
 
-	volBtc, err := strconv.ParseFloat(resp.Ticker.VolBtc, 64)
+	volQuote, err := strconv.ParseFloat(quoteVol, 64)
 	if err != nil {
 		return nil, err
 	}
@@ -136,11 +225,11 @@ func (resp *indodaxPubTickerResp) Normalize() (*indodaxPubTickerData, error) {
 	return &indodaxPubTickerData{
 		High:       high,
 		Low:        low,
-		VolDrk:     volDrk,
-		VolBtc:     volBtc,
+		VolBase:    volBase,
+		VolQuote:   volQuote,
 		Last:       last,
 		Buy:        buy,
 		Sell:       sell,
-		ServerTime: time.Unix(resp.Ticker.ServerTime, 0),
+		ServerTime: unixTime(resp.Ticker.ServerTime),
 	}, nil
 }
@@ -1,10 +1,10 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -13,58 +13,126 @@ import (
 type KrakenAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("kraken", func(opts ...Option) RateAPI { return NewKrakenAPI(opts...) })
 }
 
 // NewKrakenAPI is a constructor for KrakenAPI.
-func NewKrakenAPI() *KrakenAPI {
+func NewKrakenAPI(opts ...Option) *KrakenAPI {
+	a, _ := NewKrakenAPIForPair("DASH", "USD", opts...)
+	return a
+}
+
+// NewKrakenAPIForPair is a constructor for a KrakenAPI which fetches the rate
+// for the base/quote pair, e.g. ("DASH", "USD"). It returns an error wrapping
+// ErrPairNotFound for pairs not in krakenPairs.
+func NewKrakenAPIForPair(base, quote string, opts ...Option) (*KrakenAPI, error) {
+	base, quote, err := checkPair("Kraken", krakenPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &KrakenAPI{
 		BaseAPIURL:          "https://api.kraken.com",
-		PriceTickerEndpoint: "/0/public/Ticker?pair=DASHUSD",
-	}
+		PriceTickerEndpoint: "/0/public/Ticker?pair=" + exchangeSymbol("kraken", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// krakenPairs lists the Kraken markets which NewKrakenAPIForPair accepts.
+var krakenPairs = []string{"DASH/USD", "DASH/EUR", "DASH/BTC", "USDT/USD"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *KrakenAPI) DisplayName() string {
 	return "Kraken"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *KrakenAPI) Describe() Description {
+	return Description{
+		ID:        "kraken",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(krakenPairs),
+		Price:     LastTradePrice,
+		HasVolume: true,
+		URL:       "https://www.kraken.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Kraken API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *KrakenAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the Kraken API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *KrakenAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res krakenTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if len(res.Errors) != 0 {
+		return nil, krakenError(a.DisplayName(), res.Errors)
 	}
 
-	// parse json and extract Dash rate
-	var res krakenTickerResp
-	err = json.Unmarshal(body, &res)
-	if err != nil {
-		return nil, err
+	// Kraken keys the result by its own name for the market, which isn't
+	// always the one it was requested by, so just take the only entry.
+	if len(res.Result) != 1 {
+		return nil, badResponse(fmt.Errorf("expected 1 Kraken ticker, got %d", len(res.Result)))
+	}
+	var ticker krakenAPIResult
+	for _, t := range res.Result {
+		ticker = t
 	}
 
 	// Get a struct w/proper data types
-	data, err := res.Result.DashUSDPair.Normalize()
+	data, err := ticker.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
+	}
+
+	last, err := ParseDecimal(ticker.LastClosed[0])
+	if err != nil {
+		return nil, badResponse(err)
+	}
+	volume, err := ParseDecimal(ticker.Volume[0])
+	if err != nil {
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "USD",
-		LastPrice:       data.LastClosed.Price,
-		BaseAssetVolume: data.Volume.Today,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              data.LastClosed.Price,
+		LastPriceDecimal:       last,
+		BaseAssetVolume:        data.Volume.Today,
+		BaseAssetVolumeDecimal: volume,
+		Bid:                    optionalDecimal(ticker.Bid[0]),
+		Ask:                    optionalDecimal(ticker.Ask[0]),
+		High:                   optionalDecimal(ticker.High[0]),
+		Low:                    optionalDecimal(ticker.Low[0]),
+		Open:                   optionalDecimal(ticker.Open),
+		FetchTime:              now,
 	}
 
 	return &ri, nil
@@ -85,67 +153,43 @@ type krakenAPIResult struct {
 
 // Normalize ... does the needful.
 func (resp *krakenAPIResult) Normalize() (*krakenResult, error) {
-	askArr := make([]float64, 3)
-	for i := 0; i < 3; i++ {
-		x, err := strconv.ParseFloat(resp.Ask[i], 64)
-		if err != nil {
-			return nil, err
-		}
-		askArr[i] = x
+	askArr, err := parseKrakenArray("a", resp.Ask, 3)
+	if err != nil {
+		return nil, err
 	}
 
-	bidArr := make([]float64, 3)
-	for i := 0; i < 3; i++ {
-		x, err := strconv.ParseFloat(resp.Bid[i], 64)
-		if err != nil {
-			return nil, err
-		}
-		bidArr[i] = x
+	bidArr, err := parseKrakenArray("b", resp.Bid, 3)
+	if err != nil {
+		return nil, err
 	}
 
-	lastClosedArr := make([]float64, 2)
-	for i := 0; i < 2; i++ {
-		x, err := strconv.ParseFloat(resp.LastClosed[i], 64)
-		if err != nil {
-			return nil, err
-		}
-		lastClosedArr[i] = x
+	lastClosedArr, err := parseKrakenArray("c", resp.LastClosed, 2)
+	if err != nil {
+		return nil, err
 	}
 
-	vArr := make([]float64, 2)
-	for i := 0; i < 2; i++ {
-		x, err := strconv.ParseFloat(resp.Volume[i], 64)
-		if err != nil {
-			return nil, err
-		}
-		vArr[i] = x
+	vArr, err := parseKrakenArray("v", resp.Volume, 2)
+	if err != nil {
+		return nil, err
 	}
 
-	vwapArr := make([]float64, 2)
-	for i := 0; i < 2; i++ {
-		x, err := strconv.ParseFloat(resp.VWAP[i], 64)
-		if err != nil {
-			return nil, err
-		}
-		vwapArr[i] = x
+	vwapArr, err := parseKrakenArray("p", resp.VWAP, 2)
+	if err != nil {
+		return nil, err
 	}
 
-	lowArr := make([]float64, 2)
-	for i := 0; i < 2; i++ {
-		x, err := strconv.ParseFloat(resp.Low[i], 64)
-		if err != nil {
-			return nil, err
-		}
-		lowArr[i] = x
+	lowArr, err := parseKrakenArray("l", resp.Low, 2)
+	if err != nil {
+		return nil, err
 	}
 
-	highArr := make([]float64, 2)
-	for i := 0; i < 2; i++ {
-		x, err := strconv.ParseFloat(resp.High[i], 64)
-		if err != nil {
-			return nil, err
-		}
-		highArr[i] = x
+	highArr, err := parseKrakenArray("h", resp.High, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Trades) < 2 {
+		return nil, fmt.Errorf("kraken field %q has %d values, want 2", "t", len(resp.Trades))
 	}
 
 	open, err := strconv.ParseFloat(resp.Open, 64)
@@ -199,6 +243,25 @@ func (resp *krakenAPIResult) Normalize() (*krakenResult, error) {
 	}, nil
 }
 
+// parseKrakenArray parses the first n values of one of the string arrays in
+// a Kraken ticker, failing rather than panicking if there are fewer than n.
+func parseKrakenArray(name string, vals []string, n int) ([]float64, error) {
+	if len(vals) < n {
+		return nil, fmt.Errorf("kraken field %q has %d values, want %d", name, len(vals), n)
+	}
+
+	arr := make([]float64, n)
+	for i := 0; i < n; i++ {
+		x, err := strconv.ParseFloat(vals[i], 64)
+		if err != nil {
+			return nil, err
+		}
+		arr[i] = x
+	}
+
+	return arr, nil
+}
+
 // krakenResult is used in parsing the Kraken API response only.
 //
 // This contains the parsed fields with correct data types. It is created from
@@ -240,13 +303,41 @@ type dailyTradesArray struct {
 	Last24Hours int
 }
 
-// krakenDashResult is only used for parsing the Kraken API response.
-type krakenDashResult struct {
-	DashUSDPair krakenAPIResult `json:"DASHUSD"`
-}
-
 // krakenTickerResp is only used for parsing the Kraken API response.
 type krakenTickerResp struct {
-	Errors []string         `json:"error"`
-	Result krakenDashResult `json:"result"`
+	Errors []string                   `json:"error"`
+	Result map[string]krakenAPIResult `json:"result"`
+}
+
+// krakenError converts the error list from a Kraken response into an
+// *ExchangeError.
+//
+// Kraken errors look like "EQuery:Unknown asset pair", i.e. a severity and
+// category followed by a message. The first error decides the class.
+func krakenError(exchange string, errs []string) error {
+	code, msg := errs[0], ""
+	if i := strings.Index(code, ":"); i >= 0 {
+		code, msg = code[:i], code[i+1:]
+	}
+	for _, e := range errs[1:] {
+		msg += "; " + e
+	}
+
+	var class error
+	switch {
+	case strings.HasPrefix(errs[0], "EQuery:Unknown asset pair"):
+		class = ErrPairNotFound
+	case strings.Contains(errs[0], "Rate limit exceeded"),
+		strings.Contains(errs[0], "Too many requests"):
+		class = ErrRateLimited
+	case strings.HasPrefix(errs[0], "EService:"):
+		class = ErrExchangeUnavailable
+	}
+
+	return &ExchangeError{
+		Exchange: exchange,
+		Code:     code,
+		Msg:      msg,
+		Err:      class,
+	}
 }
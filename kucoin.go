@@ -1,9 +1,7 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
 	"strconv"
 	"time"
 )
@@ -13,65 +11,138 @@ import (
 type KuCoinAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("kucoin", func(opts ...Option) RateAPI { return NewKuCoinAPI(opts...) })
 }
 
 // NewKuCoinAPI is a constructor for KuCoinAPI.
-func NewKuCoinAPI() *KuCoinAPI {
+func NewKuCoinAPI(opts ...Option) *KuCoinAPI {
+	a, _ := NewKuCoinAPIForPair("DASH", "BTC", opts...)
+	return a
+}
+
+// NewKuCoinAPIForPair is a constructor for a KuCoinAPI which fetches the rate
+// for the base/quote pair, e.g. ("DASH", "BTC"). It returns an error wrapping
+// ErrPairNotFound for pairs not in kucoinPairs.
+func NewKuCoinAPIForPair(base, quote string, opts ...Option) (*KuCoinAPI, error) {
+	base, quote, err := checkPair("KuCoin", kucoinPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &KuCoinAPI{
 		BaseAPIURL:          "https://api.kucoin.com",
-		PriceTickerEndpoint: "/api/v1/market/orderbook/level1?symbol=DASH-BTC",
-	}
+		PriceTickerEndpoint: "/api/v1/market/orderbook/level1?symbol=" + exchangeSymbol("kucoin", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// kucoinPairs lists the KuCoin markets which NewKuCoinAPIForPair accepts.
+var kucoinPairs = []string{"DASH/BTC", "DASH/ETH", "DASH/USDT"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *KuCoinAPI) DisplayName() string {
 	return "KuCoin"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *KuCoinAPI) Describe() Description {
+	return Description{
+		ID:    "kucoin",
+		Name:  a.DisplayName(),
+		Pair:  NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs: parsePairs(kucoinPairs),
+		Price: LastTradePrice,
+		URL:   "https://www.kucoin.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the KuCoin API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *KuCoinAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the KuCoin API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *KuCoinAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res kucoinPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if res.Code != kucoinSuccessCode {
+		return nil, &ExchangeError{
+			Exchange: a.DisplayName(),
+			Code:     res.Code,
+			Msg:      res.Msg,
+			Err:      kucoinErrorClass(res.Code),
+		}
 	}
 
-	// parse json and extract Dash rate
-	var res kucoinPubTickerResp
-	err = json.Unmarshal(body, &res)
+	data, err := res.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
-	data, err := res.Normalize()
+	last, err := ParseDecimal(res.Data.Price)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "BTC",
-		LastPrice:       data.Price,
-		BaseAssetVolume: 0,
-		FetchTime:       now,
+		BaseCurrency:     a.BaseCurrency,
+		QuoteCurrency:    a.QuoteCurrency,
+		LastPrice:        data.Price,
+		LastPriceDecimal: last,
+		BaseAssetVolume:  0,
+		Bid:              optionalDecimal(res.Data.BestBid),
+		Ask:              optionalDecimal(res.Data.BestAsk),
+		FetchTime:        now,
+		ServerTime:       unixMilliTime(data.Time),
 	}
 
 	return &ri, nil
 }
 
+// kucoinSuccessCode is the code KuCoin sends with every successful response.
+const kucoinSuccessCode = "200000"
+
+// kucoinErrorClass returns the sentinel error matching a KuCoin error code, or
+// nil if there isn't one.
+func kucoinErrorClass(code string) error {
+	switch code {
+	case "429000":
+		return ErrRateLimited
+	case "900001":
+		return ErrPairNotFound
+	case "500000":
+		return ErrExchangeUnavailable
+	}
+	return nil
+}
+
 // kucoinPubTickerResp is used in parsing the KuCoin API response only.
 type kucoinPubTickerResp struct {
 	Code string `json:"code"`
+	Msg  string `json:"msg"`
 	Data struct {
 		Sequence    string `json:"sequence"`
 		BestAsk     string `json:"bestAsk"`
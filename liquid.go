@@ -1,9 +1,7 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
 	"strconv"
 	"time"
 )
@@ -13,14 +11,47 @@ import (
 type LiquidAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("liquid", func(opts ...Option) RateAPI { return NewLiquidAPI(opts...) })
 }
 
 // NewLiquidAPI is a constructor for LiquidAPI.
-func NewLiquidAPI() *LiquidAPI {
+func NewLiquidAPI(opts ...Option) *LiquidAPI {
+	a, _ := NewLiquidAPIForPair("DASH", "BTC", opts...)
+	return a
+}
+
+// NewLiquidAPIForPair is a constructor for a LiquidAPI which fetches the rate
+// for the base/quote pair, e.g. ("DASH", "BTC"). It returns an error wrapping
+// ErrPairNotFound for pairs not in liquidPairs.
+func NewLiquidAPIForPair(base, quote string, opts ...Option) (*LiquidAPI, error) {
+	base, quote, err := checkPair("Liquid", liquidPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &LiquidAPI{
 		BaseAPIURL:          "https://api.liquid.com",
-		PriceTickerEndpoint: "/products/116",
-	}
+		PriceTickerEndpoint: "/products/" + liquidProductIDs[base+"/"+quote],
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
+}
+
+// liquidPairs lists the Liquid markets which NewLiquidAPIForPair accepts.
+var liquidPairs = []string{"DASH/BTC"}
+
+// liquidProductIDs maps each market NewLiquidAPIForPair accepts to Liquid's
+// numeric product ID for it.
+var liquidProductIDs = map[string]string{
+	"DASH/BTC": "116",
 }
 
 // DisplayName returns the exchange display name. It is part of the RateAPI
@@ -29,41 +60,66 @@ func (a *LiquidAPI) DisplayName() string {
 	return "Liquid"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *LiquidAPI) Describe() Description {
+	return Description{
+		ID:         "liquid",
+		Name:       a.DisplayName(),
+		Pair:       NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:      parsePairs(liquidPairs),
+		Price:      LastTradePrice,
+		HasVolume:  true,
+		URL:        "https://www.liquid.com",
+		Deprecated: "Liquid stopped trading in 2022.",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Liquid API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *LiquidAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the Liquid API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *LiquidAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res liquidPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	data, err := res.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
-	// parse json and extract Dash rate
-	var res liquidPubTickerResp
-	err = json.Unmarshal(body, &res)
+	last, err := ParseDecimal(res.LastPrice)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
-
-	data, err := res.Normalize()
+	volume, err := ParseDecimal(res.Volume24h)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "BTC",
-		LastPrice:       data.LastPrice,
-		BaseAssetVolume: data.Volume24h,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              data.LastPrice,
+		LastPriceDecimal:       last,
+		BaseAssetVolume:        data.Volume24h,
+		BaseAssetVolumeDecimal: volume,
+		Bid:                    res.MarketBid,
+		Ask:                    res.MarketAsk,
+		FetchTime:              now,
 	}
 
 	return &ri, nil
@@ -78,9 +134,11 @@ type liquidPubTickerData struct {
 
 // liquidPubTickerResp is used in parsing the Liquid API response only.
 type liquidPubTickerResp struct {
-	ID        string `json:"id"`
-	LastPrice string `json:"last_traded_price"`
-	Volume24h string `json:"volume_24h"`
+	ID        string  `json:"id"`
+	LastPrice string  `json:"last_traded_price"`
+	Volume24h string  `json:"volume_24h"`
+	MarketBid Decimal `json:"market_bid"`
+	MarketAsk Decimal `json:"market_ask"`
 }
 
 // Normalize parses the fields in liquidPubTickerResp and returns a
@@ -1,9 +1,7 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
 	"strconv"
 	"time"
 )
@@ -13,57 +11,114 @@ import (
 type OKExAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("okex", func(opts ...Option) RateAPI { return NewOKExAPI(opts...) })
 }
 
 // NewOKExAPI is a constructor for OKExAPI.
-func NewOKExAPI() *OKExAPI {
+func NewOKExAPI(opts ...Option) *OKExAPI {
+	a, _ := NewOKExAPIForPair("DASH", "BTC", opts...)
+	return a
+}
+
+// NewOKExAPIForPair is a constructor for an OKExAPI which fetches the rate for
+// the base/quote pair, e.g. ("DASH", "BTC"). It returns an error wrapping
+// ErrPairNotFound for pairs not in okexPairs.
+func NewOKExAPIForPair(base, quote string, opts ...Option) (*OKExAPI, error) {
+	base, quote, err := checkPair("OKEx", okexPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &OKExAPI{
 		BaseAPIURL:          "https://www.okex.com",
-		PriceTickerEndpoint: "/api/spot/v3/instruments/DASH-BTC/ticker",
-	}
+		PriceTickerEndpoint: "/api/spot/v3/instruments/" + exchangeSymbol("okex", base, quote) + "/ticker",
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// okexPairs lists the OKEx markets which NewOKExAPIForPair accepts.
+var okexPairs = []string{"DASH/BTC", "DASH/ETH", "DASH/USDT"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *OKExAPI) DisplayName() string {
 	return "OKEx"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *OKExAPI) Describe() Description {
+	return Description{
+		ID:         "okex",
+		Name:       a.DisplayName(),
+		Pair:       NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:      parsePairs(okexPairs),
+		Price:      LastTradePrice,
+		HasVolume:  true,
+		URL:        "https://www.okex.com",
+		Deprecated: "OKEx is now OKX, and has retired the v3 API this adapter uses.",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the OKEx API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *OKExAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the OKEx API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *OKExAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res okexPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
+	data, err := res.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
-	// parse json and extract Dash rate
-	var res okexPubTickerResp
-	err = json.Unmarshal(body, &res)
+	last, err := ParseDecimal(res.Last)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
-
-	data, err := res.Normalize()
+	volume, err := ParseDecimal(res.QuoteVolume24h)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "BTC",
-		LastPrice:       data.Last,
-		BaseAssetVolume: data.QuoteVolume24h,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              data.Last,
+		LastPriceDecimal:       last,
+		BaseAssetVolume:        data.QuoteVolume24h,
+		BaseAssetVolumeDecimal: volume,
+		Bid:                    optionalDecimal(res.BestBid),
+		Ask:                    optionalDecimal(res.BestAsk),
+		High:                   optionalDecimal(res.High24h),
+		Low:                    optionalDecimal(res.Low24h),
+		Open:                   optionalDecimal(res.Open24h),
+		QuoteAssetVolume:       optionalDecimal(res.BaseVolume24h),
+		FetchTime:              now,
+		ServerTime:             data.Timestamp,
 	}
 
 	return &ri, nil
@@ -0,0 +1,143 @@
+package dashrates
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// defaultOutlierMinRates is the fewest rates an OutlierFilter needs before it
+// rejects anything. With two, the median is halfway between them and there's
+// no telling which is wrong.
+const defaultOutlierMinRates = 3
+
+// OutlierFilter rejects rates whose price is too far from the median price of
+// all the rates, such as a stale or broken market. A rate is rejected if it
+// exceeds either limit which is set.
+type OutlierFilter struct {
+	// MaxDeviation is the largest distance from the median allowed, as a
+	// fraction of the median, e.g. 0.1 for 10%. Zero means no limit.
+	MaxDeviation float64
+
+	// MaxMADs is the largest distance from the median allowed, as a
+	// multiple of the median absolute deviation (MAD) of all the rates.
+	// Zero means no limit. The limit isn't applied when the MAD is zero,
+	// which happens when most rates agree exactly.
+	MaxMADs float64
+
+	// MinRates is the fewest rates needed for any to be rejected. Zero
+	// means 3.
+	MinRates int
+}
+
+// Deviation is how far one rate's price is from the median price.
+type Deviation struct {
+	Exchange string
+	Price    Decimal
+	Median   Decimal
+
+	// Relative is (Price - Median) / Median, e.g. 0.3 for 30% above the
+	// median.
+	Relative float64
+
+	// MADs is |Price - Median| divided by the median absolute deviation, or
+	// 0 if that is zero.
+	MADs float64
+
+	// Rejected reports whether the OutlierFilter rejected the rate, and
+	// Reason says why.
+	Rejected bool
+	Reason   string `json:",omitempty"`
+}
+
+// Deviations works out how far each result's rate is from the median of them
+// all, and which the filter rejects. The rates should all be for the same
+// pair. Results which failed or have no price are skipped.
+func (f *OutlierFilter) Deviations(results []Result) []Deviation {
+	var rates []*RateInfo
+	var exchanges []string
+	for _, r := range results {
+		if r.Err != nil || r.Rate == nil || !r.Rate.LastPriceDecimal.IsSet() {
+			continue
+		}
+		rates = append(rates, r.Rate)
+		exchanges = append(exchanges, r.Exchange)
+	}
+
+	prices := sortedPrices(rates)
+	if len(prices) == 0 {
+		return nil
+	}
+	median := medianOf(prices)
+
+	absDevs := make([]Decimal, len(prices))
+	for i, p := range prices {
+		d := p.Sub(median)
+		if d.Sign() < 0 {
+			d = d.Neg()
+		}
+		absDevs[i] = d
+	}
+	mad := medianOf(sortedDecimals(absDevs)).Float64()
+	medianF := median.Float64()
+
+	min := f.MinRates
+	if min <= 0 {
+		min = defaultOutlierMinRates
+	}
+	filtering := len(prices) >= min
+
+	devs := make([]Deviation, 0, len(rates))
+	for i, ri := range rates {
+		diff := ri.LastPriceDecimal.Sub(median).Float64()
+		dev := Deviation{
+			Exchange: exchanges[i],
+			Price:    ri.LastPriceDecimal,
+			Median:   median,
+		}
+		if medianF != 0 {
+			dev.Relative = diff / medianF
+		}
+		if mad != 0 {
+			dev.MADs = math.Abs(diff) / mad
+		}
+
+		if filtering {
+			var reasons []string
+			if f.MaxDeviation > 0 && math.Abs(dev.Relative) > f.MaxDeviation {
+				reasons = append(reasons, fmt.Sprintf("%.1f%% from median, limit %.1f%%",
+					dev.Relative*100, f.MaxDeviation*100))
+			}
+			if f.MaxMADs > 0 && dev.MADs > f.MaxMADs {
+				reasons = append(reasons, fmt.Sprintf("%.1f MADs from median, limit %.1f",
+					dev.MADs, f.MaxMADs))
+			}
+			if len(reasons) > 0 {
+				dev.Rejected = true
+				dev.Reason = fmt.Sprintf("outlier: price %s is %s (median %s)",
+					dev.Price, strings.Join(reasons, " and "), median)
+			}
+		}
+
+		devs = append(devs, dev)
+	}
+
+	return devs
+}
+
+// filter records the deviation of each result in agg, excluding the outliers,
+// and returns the rest.
+func (f *OutlierFilter) filter(results []Result, agg *Aggregate) []Result {
+	agg.Deviations = f.Deviations(results)
+
+	// results all have prices, so Deviations has an entry for each
+	var kept []Result
+	for i, dev := range agg.Deviations {
+		if dev.Rejected {
+			agg.exclude(dev.Exchange, dev.Reason)
+			continue
+		}
+		kept = append(kept, results[i])
+	}
+	return kept
+}
@@ -1,10 +1,7 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
+	"context"
 	"strconv"
 	"time"
 )
@@ -14,63 +11,116 @@ import (
 type PoloniexAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("poloniex", func(opts ...Option) RateAPI { return NewPoloniexAPI(opts...) })
 }
 
 // NewPoloniexAPI is a constructor for PoloniexAPI.
-func NewPoloniexAPI() *PoloniexAPI {
+func NewPoloniexAPI(opts ...Option) *PoloniexAPI {
+	a, _ := NewPoloniexAPIForPair("DASH", "BTC", opts...)
+	return a
+}
+
+// NewPoloniexAPIForPair is a constructor for a PoloniexAPI which fetches the
+// rate for the base/quote pair, e.g. ("DASH", "BTC"). It returns an error
+// wrapping ErrPairNotFound for pairs not in poloniexPairs.
+func NewPoloniexAPIForPair(base, quote string, opts ...Option) (*PoloniexAPI, error) {
+	base, quote, err := checkPair("Poloniex", poloniexPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &PoloniexAPI{
 		BaseAPIURL:          "https://poloniex.com/public",
 		PriceTickerEndpoint: "?command=returnTicker",
-	}
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// poloniexPairs lists the Poloniex markets which NewPoloniexAPIForPair accepts.
+var poloniexPairs = []string{"DASH/BTC", "DASH/USDT"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *PoloniexAPI) DisplayName() string {
 	return "Poloniex"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *PoloniexAPI) Describe() Description {
+	return Description{
+		ID:        "poloniex",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(poloniexPairs),
+		Price:     LastTradePrice,
+		HasVolume: true,
+		URL:       "https://poloniex.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Poloniex API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *PoloniexAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	now := time.Now()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+	return a.FetchRateContext(context.Background())
+}
 
+// FetchRateContext gets the Dash exchange rate from the Poloniex API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *PoloniexAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
 	// parse json and extract Dash rate
 	var res poloniexPubTickerResp
-	err = json.Unmarshal(body, &res)
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
 
+	now := time.Now()
+
 	// Poloniex gets their base/quotes backwards - BTC is quote, DASH is base
-	ticker, ok := res["BTC_DASH"]
+	ticker, ok := res[exchangeSymbol("poloniex", a.BaseCurrency, a.QuoteCurrency)]
 	if !ok {
-		err = fmt.Errorf("oh no, %s does not have DASH/BTC pair", a.DisplayName())
-		return nil, err
+		return nil, pairNotFound(a.DisplayName(), a.BaseCurrency, a.QuoteCurrency)
 	}
 	data, err := ticker.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
+	}
+
+	last, err := ParseDecimal(ticker.Last)
+	if err != nil {
+		return nil, badResponse(err)
+	}
+	volume, err := ParseDecimal(ticker.BaseVolume)
+	if err != nil {
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "BTC",
-		LastPrice:       data.Last,
-		BaseAssetVolume: data.BaseVolume,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              data.Last,
+		LastPriceDecimal:       last,
+		BaseAssetVolume:        data.BaseVolume,
+		BaseAssetVolumeDecimal: volume,
+		Bid:                    optionalDecimal(ticker.HighestBid),
+		Ask:                    optionalDecimal(ticker.LowestAsk),
+		High:                   optionalDecimal(ticker.High24hr),
+		Low:                    optionalDecimal(ticker.Low24hr),
+		QuoteAssetVolume:       optionalDecimal(ticker.QuoteVolume),
+		FetchTime:              now,
 	}
 
 	return &ri, nil
@@ -1,20 +1,50 @@
 package dashrates
 
 import (
+	"context"
 	"encoding/json"
+	"strings"
 	"time"
 )
 
 // RateInfo contains information about exchange rates, including a Base and
 // Quote pair, the last price, the asset volume in terms of the Base currency,
 // and a fetch timestamp. Note that this timestamp is just for fetch time, and
-// not an API server timestamp.
+// not an API server timestamp. The exchange's own timestamp for the data, when
+// it provides one, is in ServerTime, which is otherwise left as the zero Time.
+//
+// LastPriceDecimal and BaseAssetVolumeDecimal hold the same values as
+// LastPrice and BaseAssetVolume, but exactly as the exchange reported them.
+// BaseAssetVolumeDecimal is unset when the exchange doesn't report volume.
+//
+// QuoteCurrency is always the currency the rate is really quoted in, so a
+// DASH/USDT market is reported as USDT, not USD. Rates which have been
+// converted into another quote currency, e.g. by a StablecoinConverter, list
+// each step in Conversions.
 type RateInfo struct {
-	BaseCurrency    string
-	QuoteCurrency   string
-	LastPrice       float64
-	BaseAssetVolume float64
-	FetchTime       time.Time
+	BaseCurrency           string
+	QuoteCurrency          string
+	LastPrice              float64
+	LastPriceDecimal       Decimal
+	BaseAssetVolume        float64
+	BaseAssetVolumeDecimal Decimal
+
+	// The remaining market data is optional, and each field is left unset
+	// when the exchange doesn't provide it. Bid and Ask are the best bid and
+	// ask. High, Low and Open cover the exchange's daily window, which is
+	// usually the last 24 hours, and QuoteAssetVolume is the volume over the
+	// same window in terms of the Quote currency.
+	Bid              Decimal
+	Ask              Decimal
+	High             Decimal
+	Low              Decimal
+	Open             Decimal
+	QuoteAssetVolume Decimal
+
+	FetchTime  time.Time
+	ServerTime time.Time
+
+	Conversions []Conversion `json:",omitempty"`
 }
 
 // MarshalBinary is part of the encoding.BinaryMarshaler interface
@@ -27,9 +57,59 @@ func (ri *RateInfo) UnmarshalBinary(data []byte) error {
 	return json.Unmarshal(data, ri)
 }
 
+// unixTime converts a Unix timestamp in seconds into a Time. Exchanges send 0
+// or leave the field out when they don't know, so 0 gives the zero Time.
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0)
+}
+
+// unixMilliTime converts a Unix timestamp in milliseconds into a Time, with 0
+// giving the zero Time.
+func unixMilliTime(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
 // RateAPI is an interface that describes an API which includes the Dash
 // cryptocurrency.
 type RateAPI interface {
 	DisplayName() string
 	FetchRate() (*RateInfo, error)
 }
+
+// ContextRateAPI is a RateAPI whose fetch can be cancelled or bounded by a
+// context. Use it in preference to FetchRate so that a single hung exchange
+// can't stall the caller indefinitely.
+type ContextRateAPI interface {
+	RateAPI
+	FetchRateContext(ctx context.Context) (*RateInfo, error)
+}
+
+// fetchRate fetches from api, passing ctx along if api supports it.
+func fetchRate(ctx context.Context, api RateAPI) (*RateInfo, error) {
+	if capi, ok := api.(ContextRateAPI); ok {
+		return capi.FetchRateContext(ctx)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return api.FetchRate()
+}
+
+// checkPair upper-cases base and quote and checks that they name one of the
+// "BASE/QUOTE" pairs listed, returning an error wrapping ErrPairNotFound if
+// not.
+func checkPair(exchange string, pairs []string, base, quote string) (string, string, error) {
+	base, quote = strings.ToUpper(base), strings.ToUpper(quote)
+	for _, p := range pairs {
+		if p == base+"/"+quote {
+			return base, quote, nil
+		}
+	}
+	return base, quote, pairNotFound(exchange, base, quote)
+}
@@ -0,0 +1,178 @@
+package dashrates
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// Interpolation says how a RateLookup works out the rate between samples.
+type Interpolation string
+
+// The ways of working out a rate between samples.
+const (
+	// InterpolatePrevious uses the last sample at or before the time, as a
+	// price shown on screen at that moment would have been.
+	InterpolatePrevious Interpolation = "previous"
+
+	// InterpolateLinear draws a straight line between the samples either
+	// side of the time.
+	InterpolateLinear Interpolation = "linear"
+
+	// InterpolateNearest uses whichever sample is closest to the time, the
+	// earlier one if they are equally close.
+	InterpolateNearest Interpolation = "nearest"
+)
+
+// PointRate is a rate at a point in time, with the samples it was worked out
+// from so that it can be audited.
+type PointRate struct {
+	// Rate is the rate at Time. For linear interpolation between two samples
+	// only the price is set, rounded to 16 significant digits, with Time as
+	// its FetchTime. Otherwise it is the sample used.
+	Rate *RateInfo
+
+	Time          time.Time
+	Exchange      string
+	Interpolation Interpolation
+
+	// Samples are the stored rates used, oldest first. There is one unless
+	// the rate was interpolated linearly between two.
+	Samples []*RateInfo
+}
+
+// RateLookup finds past rates for an exchange in a HistoryStore, e.g. for
+// payouts and tax reports.
+type RateLookup struct {
+	Store    *HistoryStore
+	Exchange string
+
+	// Interpolation defaults to InterpolatePrevious.
+	Interpolation Interpolation
+
+	// MaxGap is how far the samples used may be from the time asked about.
+	// For linear interpolation it limits the gap between the two samples
+	// instead. Zero means there is no limit.
+	MaxGap time.Duration
+}
+
+// NewRateLookup is a constructor for RateLookup. The exchange is the name
+// rates were stored under in the HistoryStore, usually a registry ID.
+func NewRateLookup(store *HistoryStore, exchange string) *RateLookup {
+	return &RateLookup{
+		Store:         store,
+		Exchange:      exchange,
+		Interpolation: InterpolatePrevious,
+	}
+}
+
+// RateAt returns the rate for pair at t. The error wraps ErrNoHistory if
+// there are no samples to use, or they are further away than MaxGap.
+func (l *RateLookup) RateAt(pair Pair, t time.Time) (*PointRate, error) {
+	before, after, err := l.Store.around(l.Exchange, pair, t)
+	if err != nil {
+		return nil, err
+	}
+
+	mode := l.Interpolation
+	if mode == "" {
+		mode = InterpolatePrevious
+	}
+	pr := &PointRate{
+		Time:          t,
+		Exchange:      l.Exchange,
+		Interpolation: mode,
+	}
+
+	switch mode {
+	case InterpolatePrevious:
+		if before == nil {
+			return nil, l.noHistory(pair, t, "no sample at or before it")
+		}
+		if err := l.checkGap(pair, t, t.Sub(before.FetchTime)); err != nil {
+			return nil, err
+		}
+		pr.Rate = before
+		pr.Samples = []*RateInfo{before}
+
+	case InterpolateNearest:
+		nearest := before
+		if nearest == nil || (after != nil && after.FetchTime.Sub(t) < t.Sub(before.FetchTime)) {
+			nearest = after
+		}
+		if nearest == nil {
+			return nil, l.noHistory(pair, t, "no samples")
+		}
+		gap := t.Sub(nearest.FetchTime)
+		if gap < 0 {
+			gap = -gap
+		}
+		if err := l.checkGap(pair, t, gap); err != nil {
+			return nil, err
+		}
+		pr.Rate = nearest
+		pr.Samples = []*RateInfo{nearest}
+
+	case InterpolateLinear:
+		if before != nil && before.FetchTime.Equal(t) {
+			pr.Rate = before
+			pr.Samples = []*RateInfo{before}
+			break
+		}
+		if before == nil || after == nil {
+			return nil, l.noHistory(pair, t, "no samples either side of it")
+		}
+		if err := l.checkGap(pair, t, after.FetchTime.Sub(before.FetchTime)); err != nil {
+			return nil, err
+		}
+		pr.Rate, err = interpolateLinear(pair, before, after, t)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s at %s: %w", l.Exchange, pair, t.Format(time.RFC3339), err)
+		}
+		pr.Samples = []*RateInfo{before, after}
+
+	default:
+		return nil, fmt.Errorf("unknown interpolation %q", mode)
+	}
+
+	return pr, nil
+}
+
+// checkGap returns an error if gap is more than MaxGap.
+func (l *RateLookup) checkGap(pair Pair, t time.Time, gap time.Duration) error {
+	if l.MaxGap > 0 && gap > l.MaxGap {
+		return l.noHistory(pair, t, fmt.Sprintf("samples are %s apart, more than %s", gap, l.MaxGap))
+	}
+	return nil
+}
+
+// noHistory returns an ErrNoHistory error explaining why.
+func (l *RateLookup) noHistory(pair Pair, t time.Time, why string) error {
+	return fmt.Errorf("%s %s at %s: %s: %w", l.Exchange, pair, t.Format(time.RFC3339), why, ErrNoHistory)
+}
+
+// interpolateLinear returns the price on the line between the prices of a and
+// b at t, which is between their FetchTimes. Both must have a decimal price.
+func interpolateLinear(pair Pair, a, b *RateInfo, t time.Time) (*RateInfo, error) {
+	if !a.LastPriceDecimal.IsSet() || !b.LastPriceDecimal.IsSet() {
+		return nil, badResponse(errors.New("sample has no price"))
+	}
+
+	span := b.FetchTime.Sub(a.FetchTime)
+	frac := big.NewRat(int64(t.Sub(a.FetchTime)), int64(span))
+
+	pa, pb := a.LastPriceDecimal.Rat(), b.LastPriceDecimal.Rat()
+	price := new(big.Rat).Sub(pb, pa)
+	price.Mul(price, frac)
+	price.Add(price, pa)
+
+	d := roundSignificant(price, crossRateDigits)
+	return &RateInfo{
+		BaseCurrency:     string(pair.Base),
+		QuoteCurrency:    string(pair.Quote),
+		LastPrice:        d.Float64(),
+		LastPriceDecimal: d,
+		FetchTime:        t,
+	}, nil
+}
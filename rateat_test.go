@@ -0,0 +1,133 @@
+package dashrates
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var rateAtStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// newTestLookup returns a RateLookup over DASH/USD samples of 30.10 at
+// rateAtStart and 31.3 an hour later.
+func newTestLookup(t *testing.T) *RateLookup {
+	t.Helper()
+	hs, err := OpenHistoryStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, price := range []string{"30.10", "31.3"} {
+		d, err := ParseDecimal(price)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = hs.Add("kraken", &RateInfo{
+			BaseCurrency:     "DASH",
+			QuoteCurrency:    "USD",
+			LastPrice:        d.Float64(),
+			LastPriceDecimal: d,
+			FetchTime:        rateAtStart.Add(time.Duration(i) * time.Hour),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewRateLookup(hs, "kraken")
+}
+
+func TestRateAt(t *testing.T) {
+	l := newTestLookup(t)
+	pair := NewPair("DASH", "USD")
+
+	tests := []struct {
+		mode    Interpolation
+		at      time.Duration
+		want    string
+		samples int
+	}{
+		{InterpolatePrevious, 0, "30.10", 1},
+		{InterpolatePrevious, 45 * time.Minute, "30.10", 1},
+		{InterpolatePrevious, 2 * time.Hour, "31.3", 1},
+		{InterpolateNearest, -time.Minute, "30.10", 1},
+		{InterpolateNearest, 20 * time.Minute, "30.10", 1},
+		{InterpolateNearest, 30 * time.Minute, "30.10", 1},
+		{InterpolateNearest, 45 * time.Minute, "31.3", 1},
+		{InterpolateLinear, 0, "30.10", 1},
+		{InterpolateLinear, 20 * time.Minute, "30.50000000000000", 2},
+		{InterpolateLinear, 45 * time.Minute, "31.00000000000000", 2},
+	}
+	for _, tt := range tests {
+		l.Interpolation = tt.mode
+		pr, err := l.RateAt(pair, rateAtStart.Add(tt.at))
+		if err != nil {
+			t.Errorf("%s at %s: %v", tt.mode, tt.at, err)
+			continue
+		}
+		if got := pr.Rate.LastPriceDecimal.String(); got != tt.want {
+			t.Errorf("%s at %s: got %s, want %s", tt.mode, tt.at, got, tt.want)
+		}
+		if len(pr.Samples) != tt.samples {
+			t.Errorf("%s at %s: got %d samples, want %d", tt.mode, tt.at, len(pr.Samples), tt.samples)
+		}
+	}
+}
+
+func TestRateAtNoHistory(t *testing.T) {
+	l := newTestLookup(t)
+	pair := NewPair("DASH", "USD")
+
+	tests := []struct {
+		mode   Interpolation
+		at     time.Duration
+		maxGap time.Duration
+	}{
+		{InterpolatePrevious, -time.Minute, 0},
+		{InterpolatePrevious, 45 * time.Minute, 30 * time.Minute},
+		{InterpolateNearest, 20 * time.Minute, 10 * time.Minute},
+		{InterpolateLinear, -time.Minute, 0},
+		{InterpolateLinear, 2 * time.Hour, 0},
+		{InterpolateLinear, 20 * time.Minute, 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		l.Interpolation = tt.mode
+		l.MaxGap = tt.maxGap
+		if _, err := l.RateAt(pair, rateAtStart.Add(tt.at)); !errors.Is(err, ErrNoHistory) {
+			t.Errorf("%s at %s with max gap %s: got %v, want ErrNoHistory", tt.mode, tt.at, tt.maxGap, err)
+		}
+	}
+}
+
+func TestRateAtSamplesWithoutPrice(t *testing.T) {
+	dir := t.TempDir()
+	hs, err := OpenHistoryStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ri := &RateInfo{BaseCurrency: "DASH", QuoteCurrency: "USD", LastPrice: 10, FetchTime: rateAtStart}
+	if err := hs.Add("kraken", ri); err == nil {
+		t.Error("Add accepted a rate without LastPriceDecimal")
+	}
+
+	// files written before Add checked for a price can still hold them
+	path := filepath.Join(dir, "kraken", "DASH%2FUSD"+historyExt)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	doc := `{"BaseCurrency":"DASH","QuoteCurrency":"USD","LastPrice":10,"FetchTime":"2024-01-01T00:00:00Z"}
+{"BaseCurrency":"DASH","QuoteCurrency":"USD","LastPrice":11,"FetchTime":"2024-01-01T01:00:00Z"}
+`
+	if err := ioutil.WriteFile(path, []byte(doc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	l := NewRateLookup(hs, "kraken")
+	l.Interpolation = InterpolateLinear
+	_, err = l.RateAt(NewPair("DASH", "USD"), rateAtStart.Add(30*time.Minute))
+	if !errors.Is(err, ErrBadResponse) {
+		t.Errorf("got %v, want ErrBadResponse", err)
+	}
+}
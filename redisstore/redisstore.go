@@ -0,0 +1,224 @@
+// Package redisstore stores fetched Dash exchange rates in Redis.
+//
+// For each exchange and pair, a Store keeps the latest rate under a key which
+// expires, appends every rate to a capped stream as history, and publishes it
+// on a channel so other processes can follow updates as they happen. Rates
+// are stored using RateInfo's MarshalBinary.
+package redisstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	dashrates "github.com/dcginfra/dashrates"
+	"github.com/go-redis/redis/v8"
+)
+
+// Defaults for a Store.
+const (
+	DefaultPrefix     = "dashrates"
+	DefaultTTL        = 10 * time.Minute
+	DefaultHistoryLen = 10000
+)
+
+// ErrNotFound is returned when there is no rate stored, or it has expired.
+var ErrNotFound = errors.New("rate not found")
+
+// Update is a stored rate, as published to subscribers.
+type Update struct {
+	Exchange string
+	Rate     *dashrates.RateInfo
+}
+
+// MarshalBinary is part of the encoding.BinaryMarshaler interface
+func (u *Update) MarshalBinary() ([]byte, error) {
+	return json.Marshal(u)
+}
+
+// UnmarshalBinary is part of the encoding.BinaryUnmarshaler interface
+func (u *Update) UnmarshalBinary(data []byte) error {
+	return json.Unmarshal(data, u)
+}
+
+// Store saves rates to Redis. Keys are named after Prefix, the exchange and
+// the pair, e.g. "dashrates:latest:kraken:DASH/USD" for the latest rate and
+// "dashrates:history:kraken:DASH/USD" for the history stream. Updates are
+// published on Channel.
+//
+// The client can be anything go-redis can talk to, including an in-process
+// stand-in such as miniredis for tests.
+type Store struct {
+	Client redis.UniversalClient
+	Prefix string
+
+	// TTL is how long the latest rate is kept after it was saved. Zero keeps
+	// it until it is overwritten.
+	TTL time.Duration
+
+	// HistoryLen caps each history stream at about this many entries. Zero
+	// leaves them uncapped.
+	HistoryLen int64
+
+	// Channel is where updates are published. Empty disables publishing.
+	Channel string
+}
+
+// NewStore is a constructor for Store.
+func NewStore(client redis.UniversalClient) *Store {
+	return &Store{
+		Client:     client,
+		Prefix:     DefaultPrefix,
+		TTL:        DefaultTTL,
+		HistoryLen: DefaultHistoryLen,
+		Channel:    DefaultPrefix + ":updates",
+	}
+}
+
+// Save stores ri as the latest rate for exchange, which is usually its
+// registry ID, adds it to the history stream and publishes it. These all
+// happen in a single transaction.
+func (s *Store) Save(ctx context.Context, exchange string, ri *dashrates.RateInfo) error {
+	if ri == nil {
+		return fmt.Errorf("redisstore: nil rate for %s", exchange)
+	}
+	data, err := ri.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	pair := dashrates.NewPair(ri.BaseCurrency, ri.QuoteCurrency)
+
+	_, err = s.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, s.latestKey(exchange, pair), data, s.TTL)
+		p.XAdd(ctx, &redis.XAddArgs{
+			Stream: s.historyKey(exchange, pair),
+			MaxLen: s.HistoryLen,
+			Approx: true,
+			Values: []interface{}{"rate", data},
+		})
+		if s.Channel != "" {
+			p.Publish(ctx, s.Channel, &Update{Exchange: exchange, Rate: ri})
+		}
+		return nil
+	})
+	return err
+}
+
+// SaveResults saves every successfully fetched rate in rs, under each
+// result's ID, or its exchange name if it has none.
+func (s *Store) SaveResults(ctx context.Context, rs *dashrates.ResultSet) error {
+	for _, r := range rs.Results {
+		if r.Err != nil || r.Rate == nil {
+			continue
+		}
+		exchange := r.ID
+		if exchange == "" {
+			exchange = r.Exchange
+		}
+		if err := s.Save(ctx, exchange, r.Rate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Latest returns the latest rate saved for exchange and pair, or ErrNotFound
+// if there isn't one.
+func (s *Store) Latest(ctx context.Context, exchange string, pair dashrates.Pair) (*dashrates.RateInfo, error) {
+	data, err := s.Client.Get(ctx, s.latestKey(exchange, pair)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ri dashrates.RateInfo
+	if err := ri.UnmarshalBinary(data); err != nil {
+		return nil, err
+	}
+	return &ri, nil
+}
+
+// History returns the rates saved for exchange and pair between start and
+// end inclusive, oldest first. Times are when each rate was saved, to the
+// millisecond, and a zero start or end leaves that end of the range open.
+func (s *Store) History(ctx context.Context, exchange string, pair dashrates.Pair, start, end time.Time) ([]*dashrates.RateInfo, error) {
+	from, to := "-", "+"
+	if !start.IsZero() {
+		from = strconv.FormatInt(start.UnixNano()/int64(time.Millisecond), 10)
+	}
+	if !end.IsZero() {
+		to = strconv.FormatInt(end.UnixNano()/int64(time.Millisecond), 10)
+	}
+
+	msgs, err := s.Client.XRange(ctx, s.historyKey(exchange, pair), from, to).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]*dashrates.RateInfo, 0, len(msgs))
+	for _, msg := range msgs {
+		data, ok := msg.Values["rate"].(string)
+		if !ok {
+			return nil, fmt.Errorf("redisstore: history entry %s has no rate", msg.ID)
+		}
+		var ri dashrates.RateInfo
+		if err := ri.UnmarshalBinary([]byte(data)); err != nil {
+			return nil, err
+		}
+		rates = append(rates, &ri)
+	}
+	return rates, nil
+}
+
+// Subscribe returns a channel of updates published by any Store using the
+// same Redis server and Channel. Malformed messages are skipped. The channel
+// is closed once ctx is done.
+func (s *Store) Subscribe(ctx context.Context) (<-chan *Update, error) {
+	ps := s.Client.Subscribe(ctx, s.Channel)
+	// wait for the subscription to be confirmed, so no updates are missed
+	if _, err := ps.Receive(ctx); err != nil {
+		ps.Close()
+		return nil, err
+	}
+
+	updates := make(chan *Update)
+	go func() {
+		defer close(updates)
+		defer ps.Close()
+
+		msgs := ps.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-msgs:
+				if !ok {
+					return
+				}
+				var u Update
+				if err := u.UnmarshalBinary([]byte(msg.Payload)); err != nil {
+					continue
+				}
+				select {
+				case updates <- &u:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return updates, nil
+}
+
+func (s *Store) latestKey(exchange string, pair dashrates.Pair) string {
+	return s.Prefix + ":latest:" + exchange + ":" + pair.String()
+}
+
+func (s *Store) historyKey(exchange string, pair dashrates.Pair) string {
+	return s.Prefix + ":history:" + exchange + ":" + pair.String()
+}
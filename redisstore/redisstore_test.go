@@ -0,0 +1,193 @@
+package redisstore
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	dashrates "github.com/dcginfra/dashrates"
+	"github.com/go-redis/redis/v8"
+)
+
+func newTestStore(t *testing.T) (*Store, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+	return NewStore(client), mr
+}
+
+func testRate(price int64) *dashrates.RateInfo {
+	d := dashrates.NewDecimal(price, 0)
+	return &dashrates.RateInfo{
+		BaseCurrency:     "DASH",
+		QuoteCurrency:    "USD",
+		LastPrice:        d.Float64(),
+		LastPriceDecimal: d,
+		FetchTime:        time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+var dashUSD = dashrates.NewPair("DASH", "USD")
+
+func TestSaveLatest(t *testing.T) {
+	s, mr := newTestStore(t)
+	ctx := context.Background()
+
+	if _, err := s.Latest(ctx, "kraken", dashUSD); err != ErrNotFound {
+		t.Fatalf("Latest before Save: got %v, want ErrNotFound", err)
+	}
+
+	for _, price := range []int64{30, 31} {
+		if err := s.Save(ctx, "kraken", testRate(price)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ri, err := s.Latest(ctx, "kraken", dashUSD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ri.LastPriceDecimal.String(); got != "31" {
+		t.Errorf("latest price: got %s, want 31", got)
+	}
+	if !ri.FetchTime.Equal(testRate(0).FetchTime) {
+		t.Errorf("latest FetchTime: got %s", ri.FetchTime)
+	}
+
+	if ttl := mr.TTL("dashrates:latest:kraken:DASH/USD"); ttl != DefaultTTL {
+		t.Errorf("latest TTL: got %s, want %s", ttl, DefaultTTL)
+	}
+	mr.FastForward(DefaultTTL)
+	if _, err := s.Latest(ctx, "kraken", dashUSD); err != ErrNotFound {
+		t.Errorf("Latest after expiry: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	start := time.Now()
+	for _, price := range []int64{30, 31, 32} {
+		if err := s.Save(ctx, "kraken", testRate(price)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Save(ctx, "binance", testRate(40)); err != nil {
+		t.Fatal(err)
+	}
+
+	rates, err := s.History(ctx, "kraken", dashUSD, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 3 {
+		t.Fatalf("got %d rates, want 3", len(rates))
+	}
+	for i, want := range []string{"30", "31", "32"} {
+		if got := rates[i].LastPriceDecimal.String(); got != want {
+			t.Errorf("rate %d: got %s, want %s", i, got, want)
+		}
+	}
+
+	rates, err = s.History(ctx, "kraken", dashUSD, start.Add(-time.Hour), start.Add(-time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 0 {
+		t.Errorf("got %d rates from before saving, want 0", len(rates))
+	}
+}
+
+func TestHistoryLen(t *testing.T) {
+	s, mr := newTestStore(t)
+	ctx := context.Background()
+	s.HistoryLen = 3
+
+	for price := int64(1); price <= 10; price++ {
+		if err := s.Save(ctx, "kraken", testRate(price)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// MAXLEN ~ lets Redis keep a few more than asked, but miniredis trims
+	// exactly
+	entries, err := mr.Stream("dashrates:history:kraken:DASH/USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("got %d history entries, want 3", len(entries))
+	}
+
+	rates, err := s.History(ctx, "kraken", dashUSD, time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rates[len(rates)-1].LastPriceDecimal.String(); got != "10" {
+		t.Errorf("newest history entry: got %s, want 10", got)
+	}
+}
+
+func TestSaveResults(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+
+	rs := &dashrates.ResultSet{Results: []dashrates.Result{
+		{ID: "kraken", Exchange: "Kraken", Rate: testRate(30)},
+		{Exchange: "Some Exchange", Rate: testRate(31)},
+		{ID: "binance", Exchange: "Binance", Err: dashrates.ErrRateLimited},
+	}}
+	if err := s.SaveResults(ctx, rs); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := s.Latest(ctx, "kraken", dashUSD); err != nil {
+		t.Errorf("kraken: %v", err)
+	}
+	if _, err := s.Latest(ctx, "Some Exchange", dashUSD); err != nil {
+		t.Errorf("result without ID: %v", err)
+	}
+	if _, err := s.Latest(ctx, "binance", dashUSD); err != ErrNotFound {
+		t.Errorf("failed result: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	s, mr := newTestStore(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	updates, err := s.Subscribe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// malformed messages are skipped
+	mr.Publish(s.Channel, "not json")
+
+	if err := s.Save(ctx, "kraken", testRate(30)); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case u := <-updates:
+		if u.Exchange != "kraken" || u.Rate.LastPriceDecimal.String() != "30" {
+			t.Errorf("got update %s %s, want kraken 30", u.Exchange, u.Rate.LastPriceDecimal)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no update received")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Error("got an update after cancelling, want the channel closed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("updates channel not closed after cancelling")
+	}
+}
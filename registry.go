@@ -0,0 +1,65 @@
+package dashrates
+
+import (
+	"sort"
+	"sync"
+)
+
+// Factory builds an adapter with its default pair, as the NewXAPI
+// constructors do.
+type Factory func(opts ...Option) RateAPI
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes an adapter available by id, which should be short, stable
+// and lower-case, e.g. "kraken". Each adapter in the package registers itself
+// this way. Register panics if id is empty or already registered, or if
+// factory is nil.
+func Register(id string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if id == "" {
+		panic("dashrates: Register with empty id")
+	}
+	if factory == nil {
+		panic("dashrates: Register factory is nil for " + id)
+	}
+	if _, dup := registry[id]; dup {
+		panic("dashrates: Register called twice for " + id)
+	}
+	registry[id] = factory
+}
+
+// Lookup returns the Factory registered under id.
+func Lookup(id string) (Factory, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	factory, ok := registry[id]
+	return factory, ok
+}
+
+// IDs returns the IDs of every registered adapter, sorted.
+func IDs() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	ids := make([]string, 0, len(registry))
+	for id := range registry {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// All builds every registered adapter with opts, in ID order.
+func All(opts ...Option) []RateAPI {
+	ids := IDs()
+	apis := make([]RateAPI, 0, len(ids))
+	for _, id := range ids {
+		factory, _ := Lookup(id)
+		apis = append(apis, factory(opts...))
+	}
+	return apis
+}
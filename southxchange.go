@@ -1,9 +1,8 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
+	"errors"
 	"time"
 )
 
@@ -12,52 +11,99 @@ import (
 type SouthXchangeAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("southxchange", func(opts ...Option) RateAPI { return NewSouthXchangeAPI(opts...) })
 }
 
 // NewSouthXchangeAPI is a constructor for SouthXchangeAPI.
-func NewSouthXchangeAPI() *SouthXchangeAPI {
+func NewSouthXchangeAPI(opts ...Option) *SouthXchangeAPI {
+	a, _ := NewSouthXchangeAPIForPair("DASH", "BTC", opts...)
+	return a
+}
+
+// NewSouthXchangeAPIForPair is a constructor for a SouthXchangeAPI which
+// fetches the rate for the base/quote pair, e.g. ("DASH", "BTC"). It returns an
+// error wrapping ErrPairNotFound for pairs not in southXchangePairs.
+func NewSouthXchangeAPIForPair(base, quote string, opts ...Option) (*SouthXchangeAPI, error) {
+	base, quote, err := checkPair("SouthXchange", southXchangePairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &SouthXchangeAPI{
 		BaseAPIURL:          "https://www.southxchange.com",
-		PriceTickerEndpoint: "/api/price/DASH/BTC",
-	}
+		PriceTickerEndpoint: "/api/price/" + exchangeSymbol("southxchange", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// southXchangePairs lists the SouthXchange markets which
+// NewSouthXchangeAPIForPair accepts.
+var southXchangePairs = []string{"DASH/BTC"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *SouthXchangeAPI) DisplayName() string {
 	return "SouthXchange"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *SouthXchangeAPI) Describe() Description {
+	return Description{
+		ID:        "southxchange",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(southXchangePairs),
+		Price:     LastTradePrice,
+		HasVolume: true,
+		URL:       "https://www.southxchange.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the SouthXchange API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *SouthXchangeAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the SouthXchange API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *SouthXchangeAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res southxchangePubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	// parse json and extract Dash rate
-	var res southxchangePubTickerResp
-	err = json.Unmarshal(body, &res)
-	if err != nil {
-		return nil, err
+	if !res.Last.IsSet() {
+		return nil, badResponse(errors.New("no price in response"))
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "BTC",
-		LastPrice:       res.Last,
-		BaseAssetVolume: res.Volume24Hr,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              res.Last.Float64(),
+		LastPriceDecimal:       res.Last,
+		BaseAssetVolume:        res.Volume24Hr.Float64(),
+		BaseAssetVolumeDecimal: res.Volume24Hr,
+		Bid:                    res.Bid,
+		Ask:                    res.Ask,
+		FetchTime:              now,
 	}
 
 	return &ri, nil
@@ -65,9 +111,9 @@ func (a *SouthXchangeAPI) FetchRate() (*RateInfo, error) {
 
 // southxchangePubTickerResp is used in parsing the SouthXchange API response only.
 type southxchangePubTickerResp struct {
-	Bid           float64 `json:"Bid"`
-	Ask           float64 `json:"Ask"`
-	Last          float64 `json:"Last"`
+	Bid           Decimal `json:"Bid"`
+	Ask           Decimal `json:"Ask"`
+	Last          Decimal `json:"Last"`
 	Variation24Hr float64 `json:"Variation24Hr"`
-	Volume24Hr    float64 `json:"Volume24Hr"`
+	Volume24Hr    Decimal `json:"Volume24Hr"`
 }
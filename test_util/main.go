@@ -5,53 +5,45 @@ package main
 // updated.
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	dashrates "github.com/dcginfra/dashrates"
 )
 
 func main() {
-	// For each exchange rate API, try to pull the rate
-	apis := []dashrates.RateAPI{
-		dashrates.NewBiboxAPI(),
-		dashrates.NewBigONEAPI(),
-		dashrates.NewBinanceAPI(),
-		dashrates.NewBitbnsAPI(),
-		dashrates.NewBitfinexAPI(),
-		dashrates.NewBittrexAPI(),
-		dashrates.NewBvnexAPI(),
-		dashrates.NewCexAPI(),
-		dashrates.NewCoinCapAPI(),
-		dashrates.NewCoinbaseAPI(),
-		dashrates.NewCoinbaseProAPI(),
-		dashrates.NewCrex24API(),
-		dashrates.NewDigifinexAPI(),
-		dashrates.NewExmoAPI(),
-		dashrates.NewHitBTCAPI(),
-		dashrates.NewHuobiAPI(),
-		dashrates.NewIndodaxAPI(),
-		dashrates.NewKrakenAPI(),
-		dashrates.NewKuCoinAPI(),
-		dashrates.NewLiquidAPI(),
-		dashrates.NewOKExAPI(),
-		dashrates.NewPoloniexAPI(),
-		dashrates.NewSouthXchangeAPI(),
-		dashrates.NewTrivAPI(),
-		dashrates.NewUpholdAPI(),
-		dashrates.NewWhiteBITAPI(),
-		dashrates.NewYobitAPI(),
+	// Check the exchanges named on the command line, or every registered
+	// exchange if none are given.
+	apis := dashrates.All()
+	if len(os.Args) > 1 {
+		apis = nil
+		for _, id := range os.Args[1:] {
+			factory, ok := dashrates.Lookup(id)
+			if !ok {
+				fmt.Fprintf(os.Stderr, "unknown exchange %q, expected one of: %s\n",
+					id, strings.Join(dashrates.IDs(), ", "))
+				os.Exit(2)
+			}
+			apis = append(apis, factory())
+		}
 	}
 
-	for _, api := range apis {
-		_, err := api.FetchRate()
-		if err != nil {
+	// Pull the rate from every exchange at once, so that a few slow ones
+	// don't hold up the rest
+	rs := dashrates.FetchAll(context.Background(), apis, dashrates.FetchAllOptions{
+		Timeout: 30 * time.Second,
+	})
+	for _, res := range rs.Results {
+		if res.Err != nil {
 			// print err message to stderr
-			fmt.Fprintf(os.Stderr, "error fetching %s: %v\n", api.DisplayName(), err.Error())
+			fmt.Fprintf(os.Stderr, "error fetching %s: %v\n", res.Exchange, res.Err.Error())
 			// print a <exch> BAD message to stdout
-			fmt.Fprintf(os.Stdout, "%v ERROR\n", api.DisplayName())
+			fmt.Fprintf(os.Stdout, "%v ERROR\n", res.Exchange)
 		} else {
-			fmt.Fprintf(os.Stdout, "%v OK\n", api.DisplayName())
+			fmt.Fprintf(os.Stdout, "%v OK\n", res.Exchange)
 		}
 	}
 }
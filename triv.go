@@ -1,9 +1,8 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
+	"errors"
 	"time"
 )
 
@@ -12,60 +11,106 @@ import (
 type TrivAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("triv", func(opts ...Option) RateAPI { return NewTrivAPI(opts...) })
 }
 
 // NewTrivAPI is a constructor for TrivAPI.
-func NewTrivAPI() *TrivAPI {
+func NewTrivAPI(opts ...Option) *TrivAPI {
+	a, _ := NewTrivAPIForPair("DASH", "USD", opts...)
+	return a
+}
+
+// NewTrivAPIForPair is a constructor for a TrivAPI which fetches the rate for
+// the base/quote pair, e.g. ("DASH", "USD"). It returns an error wrapping
+// ErrPairNotFound for pairs not in trivPairs.
+func NewTrivAPIForPair(base, quote string, opts ...Option) (*TrivAPI, error) {
+	base, quote, err := checkPair("Triv", trivPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &TrivAPI{
 		BaseAPIURL:          "https://triv.id",
-		PriceTickerEndpoint: "/api/v1/config/ticker?pair=USD",
-	}
+		PriceTickerEndpoint: "/api/v1/config/ticker?pair=" + exchangeCurrency("triv", quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// trivPairs lists the Triv markets which NewTrivAPIForPair accepts.
+var trivPairs = []string{"DASH/USD"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *TrivAPI) DisplayName() string {
 	return "Triv"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *TrivAPI) Describe() Description {
+	return Description{
+		ID:    "triv",
+		Name:  a.DisplayName(),
+		Pair:  NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs: parsePairs(trivPairs),
+		Price: BidPrice,
+		URL:   "https://triv.co.id",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Triv API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *TrivAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	now := time.Now()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+	return a.FetchRateContext(context.Background())
+}
 
+// FetchRateContext gets the Dash exchange rate from the Triv API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *TrivAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
 	// parse json and extract Dash rate
-	var res []*TrivPriceResp
-
-	err = json.Unmarshal(body, &res)
+	var res []*trivPriceData
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
 
-	var x2 []*TrivPriceResp
+	now := time.Now()
+
+	var x2 []*trivPriceData
 	for _, v := range res {
-		if v.Code == "DASH" {
+		if v != nil && v.Code == a.BaseCurrency {
 			x2 = append(x2, v)
 		}
 	}
+	if len(x2) == 0 {
+		return nil, pairNotFound(a.DisplayName(), a.BaseCurrency, a.QuoteCurrency)
+	}
+
+	if !x2[0].BuyDecimal.IsSet() {
+		return nil, badResponse(errors.New("no price in response"))
+	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "USD",
-		LastPrice:       x2[0].Buy,
-		BaseAssetVolume: 0,
-		FetchTime:       now,
+		BaseCurrency:     a.BaseCurrency,
+		QuoteCurrency:    a.QuoteCurrency,
+		LastPrice:        x2[0].BuyDecimal.Float64(),
+		LastPriceDecimal: x2[0].BuyDecimal,
+		Bid:              x2[0].BuyDecimal,
+		Ask:              x2[0].SellDecimal,
+		BaseAssetVolume:  0,
+		FetchTime:        now,
 	}
 
 	return &ri, nil
@@ -78,3 +123,12 @@ type TrivPriceResp struct {
 	Sell float64 `json:"sell"`
 	Buy  float64 `json:"buy"`
 }
+
+// trivPriceData is TrivPriceResp with the prices decoded exactly. Its
+// BuyDecimal and SellDecimal take the "buy" and "sell" fields, leaving the
+// embedded Buy and Sell as zero.
+type trivPriceData struct {
+	TrivPriceResp
+	BuyDecimal  Decimal `json:"buy"`
+	SellDecimal Decimal `json:"sell"`
+}
@@ -1,9 +1,7 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
 	"strconv"
 	"time"
 )
@@ -13,57 +11,102 @@ import (
 type UpholdAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("uphold", func(opts ...Option) RateAPI { return NewUpholdAPI(opts...) })
 }
 
 // NewUpholdAPI is a constructor for UpholdAPI.
-func NewUpholdAPI() *UpholdAPI {
+func NewUpholdAPI(opts ...Option) *UpholdAPI {
+	a, _ := NewUpholdAPIForPair("DASH", "USD", opts...)
+	return a
+}
+
+// NewUpholdAPIForPair is a constructor for an UpholdAPI which fetches the rate
+// for the base/quote pair, e.g. ("DASH", "USD"). It returns an error wrapping
+// ErrPairNotFound for pairs not in upholdPairs.
+func NewUpholdAPIForPair(base, quote string, opts ...Option) (*UpholdAPI, error) {
+	base, quote, err := checkPair("Uphold", upholdPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &UpholdAPI{
 		BaseAPIURL:          "https://api.uphold.com",
-		PriceTickerEndpoint: "/v0/ticker/DASHUSD",
-	}
+		PriceTickerEndpoint: "/v0/ticker/" + exchangeSymbol("uphold", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// upholdPairs lists the Uphold markets which NewUpholdAPIForPair accepts.
+var upholdPairs = []string{"DASH/USD", "DASH/EUR", "DASH/GBP", "DASH/BTC"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *UpholdAPI) DisplayName() string {
 	return "Uphold"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *UpholdAPI) Describe() Description {
+	return Description{
+		ID:    "uphold",
+		Name:  a.DisplayName(),
+		Pair:  NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs: parsePairs(upholdPairs),
+		Price: AskPrice,
+		URL:   "https://uphold.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Uphold API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *UpholdAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the Uphold API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *UpholdAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res upholdPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
-
-	// parse json and extract Dash rate
-	var res upholdPubTickerResp
-	err = json.Unmarshal(body, &res)
+	data, err := res.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
-	data, err := res.Normalize()
+	last, err := ParseDecimal(res.Ask)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "USD",
-		LastPrice:       data.Ask,
-		BaseAssetVolume: 0,
-		FetchTime:       now,
+		BaseCurrency:     a.BaseCurrency,
+		QuoteCurrency:    a.QuoteCurrency,
+		LastPrice:        data.Ask,
+		LastPriceDecimal: last,
+		BaseAssetVolume:  0,
+		Bid:              optionalDecimal(res.Bid),
+		Ask:              optionalDecimal(res.Ask),
+		FetchTime:        now,
 	}
 
 	return &ri, nil
@@ -1,9 +1,7 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"context"
 	"strconv"
 	"time"
 )
@@ -13,57 +11,119 @@ import (
 type WhiteBITAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("whitebit", func(opts ...Option) RateAPI { return NewWhiteBITAPI(opts...) })
 }
 
 // NewWhiteBITAPI is a constructor for WhiteBITAPI.
-func NewWhiteBITAPI() *WhiteBITAPI {
+func NewWhiteBITAPI(opts ...Option) *WhiteBITAPI {
+	a, _ := NewWhiteBITAPIForPair("DASH", "USD", opts...)
+	return a
+}
+
+// NewWhiteBITAPIForPair is a constructor for a WhiteBITAPI which fetches the
+// rate for the base/quote pair, e.g. ("DASH", "USD"). It returns an error
+// wrapping ErrPairNotFound for pairs not in whitebitPairs.
+func NewWhiteBITAPIForPair(base, quote string, opts ...Option) (*WhiteBITAPI, error) {
+	base, quote, err := checkPair("WhiteBIT", whitebitPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &WhiteBITAPI{
 		BaseAPIURL:          "https://whitebit.com",
-		PriceTickerEndpoint: "/api/v1/public/ticker?market=DASH_USD",
-	}
+		PriceTickerEndpoint: "/api/v1/public/ticker?market=" + exchangeSymbol("whitebit", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// whitebitPairs lists the WhiteBIT markets which NewWhiteBITAPIForPair accepts.
+var whitebitPairs = []string{"DASH/USD", "DASH/BTC", "DASH/USDT"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *WhiteBITAPI) DisplayName() string {
 	return "WhiteBIT"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *WhiteBITAPI) Describe() Description {
+	return Description{
+		ID:        "whitebit",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(whitebitPairs),
+		Price:     LastTradePrice,
+		HasVolume: true,
+		URL:       "https://whitebit.com",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the WhiteBIT API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *WhiteBITAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
+	return a.FetchRateContext(context.Background())
+}
+
+// FetchRateContext gets the Dash exchange rate from the WhiteBIT API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *WhiteBITAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
+	// parse json and extract Dash rate
+	var res whitebitPubTickerResp
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
 	now := time.Now()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	if !res.Success {
+		return nil, &ExchangeError{
+			Exchange: a.DisplayName(),
+			Msg:      res.Message,
+		}
 	}
 
-	// parse json and extract Dash rate
-	var res whitebitPubTickerResp
-	err = json.Unmarshal(body, &res)
+	data, err := res.Normalize()
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
 	}
 
-	data, err := res.Normalize()
+	last, err := ParseDecimal(res.Result.Last)
 	if err != nil {
-		return nil, err
+		return nil, badResponse(err)
+	}
+	volume, err := ParseDecimal(res.Result.Volume)
+	if err != nil {
+		return nil, badResponse(err)
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "USD",
-		LastPrice:       data.Last,
-		BaseAssetVolume: data.Volume,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              data.Last,
+		LastPriceDecimal:       last,
+		BaseAssetVolume:        data.Volume,
+		BaseAssetVolumeDecimal: volume,
+		Bid:                    optionalDecimal(res.Result.Bid),
+		Ask:                    optionalDecimal(res.Result.Ask),
+		High:                   optionalDecimal(res.Result.High),
+		Low:                    optionalDecimal(res.Result.Low),
+		Open:                   optionalDecimal(res.Result.Open),
+		QuoteAssetVolume:       optionalDecimal(res.Result.Deal),
+		FetchTime:              now,
 	}
 
 	return &ri, nil
@@ -1,10 +1,8 @@
 package dashrates
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
-	"net/http"
+	"context"
+	"errors"
 	"time"
 )
 
@@ -13,57 +11,106 @@ import (
 type YobitAPI struct {
 	BaseAPIURL          string
 	PriceTickerEndpoint string
+	BaseCurrency        string
+	QuoteCurrency       string
+	Client              Doer
+}
+
+func init() {
+	Register("yobit", func(opts ...Option) RateAPI { return NewYobitAPI(opts...) })
 }
 
 // NewYobitAPI is a constructor for YobitAPI.
-func NewYobitAPI() *YobitAPI {
+func NewYobitAPI(opts ...Option) *YobitAPI {
+	a, _ := NewYobitAPIForPair("DASH", "USD", opts...)
+	return a
+}
+
+// NewYobitAPIForPair is a constructor for a YobitAPI which fetches the rate for
+// the base/quote pair, e.g. ("DASH", "USD"). It returns an error wrapping
+// ErrPairNotFound for pairs not in yobitPairs.
+func NewYobitAPIForPair(base, quote string, opts ...Option) (*YobitAPI, error) {
+	base, quote, err := checkPair("Yobit", yobitPairs, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	o := newOptions(opts)
 	return &YobitAPI{
 		BaseAPIURL:          "https://yobit.net",
-		PriceTickerEndpoint: "/api/3/ticker/dash_usd",
-	}
+		PriceTickerEndpoint: "/api/3/ticker/" + exchangeSymbol("yobit", base, quote),
+		BaseCurrency:        base,
+		QuoteCurrency:       quote,
+		Client:              o.client,
+	}, nil
 }
 
+// yobitPairs lists the Yobit markets which NewYobitAPIForPair accepts.
+var yobitPairs = []string{"DASH/USD", "DASH/BTC"}
+
 // DisplayName returns the exchange display name. It is part of the RateAPI
 // interface implementation.
 func (a *YobitAPI) DisplayName() string {
 	return "Yobit"
 }
 
+// Describe returns metadata about the adapter. It is part of the DescribedAPI
+// interface implementation.
+func (a *YobitAPI) Describe() Description {
+	return Description{
+		ID:        "yobit",
+		Name:      a.DisplayName(),
+		Pair:      NewPair(a.BaseCurrency, a.QuoteCurrency),
+		Pairs:     parsePairs(yobitPairs),
+		Price:     LastTradePrice,
+		HasVolume: true,
+		URL:       "https://yobit.net",
+	}
+}
+
 // FetchRate gets the Dash exchange rate from the Yobit API.
 //
 // This is part of the RateAPI interface implementation.
 func (a *YobitAPI) FetchRate() (*RateInfo, error) {
-	resp, err := http.Get(a.BaseAPIURL + a.PriceTickerEndpoint)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	now := time.Now()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
-	}
+	return a.FetchRateContext(context.Background())
+}
 
+// FetchRateContext gets the Dash exchange rate from the Yobit API, giving up
+// when ctx is cancelled or its deadline passes.
+//
+// This is part of the ContextRateAPI interface implementation.
+func (a *YobitAPI) FetchRateContext(ctx context.Context) (*RateInfo, error) {
 	// parse json and extract Dash rate
 	var res yobitPubTickerResp
-	err = json.Unmarshal(body, &res)
+	err := getJSON(ctx, a.Client, a.BaseAPIURL+a.PriceTickerEndpoint, &res)
 	if err != nil {
 		return nil, err
 	}
-	data, ok := res["dash_usd"]
+
+	now := time.Now()
+	data, ok := res[exchangeSymbol("yobit", a.BaseCurrency, a.QuoteCurrency)]
 	if !ok {
-		err = fmt.Errorf("oh no, %s does not have DASH/USD pair", a.DisplayName())
-		return nil, err
+		return nil, pairNotFound(a.DisplayName(), a.BaseCurrency, a.QuoteCurrency)
+	}
+
+	if !data.Last.IsSet() {
+		return nil, badResponse(errors.New("no price in response"))
 	}
 
 	ri := RateInfo{
-		BaseCurrency:    "DASH",
-		QuoteCurrency:   "USD",
-		LastPrice:       data.Last,
-		BaseAssetVolume: data.BaseVolume,
-		FetchTime:       now,
+		BaseCurrency:           a.BaseCurrency,
+		QuoteCurrency:          a.QuoteCurrency,
+		LastPrice:              data.Last.Float64(),
+		LastPriceDecimal:       data.Last,
+		BaseAssetVolume:        data.BaseVolume.Float64(),
+		BaseAssetVolumeDecimal: data.BaseVolume,
+		Bid:                    data.Buy,
+		Ask:                    data.Sell,
+		High:                   data.High,
+		Low:                    data.Low,
+		QuoteAssetVolume:       data.QuoteVolume,
+		FetchTime:              now,
+		ServerTime:             unixTime(int64(data.Updated)),
 	}
 
 	return &ri, nil
@@ -76,13 +123,13 @@ type yobitPubTickerResp map[string]yobitPubTickerData
 //
 // Like Poloniex and Bittrex, Yobit gets base and quote volume flipped.
 type yobitPubTickerData struct {
-	High        float64 `json:"high"`
-	Low         float64 `json:"low"`
+	High        Decimal `json:"high"`
+	Low         Decimal `json:"low"`
 	Avg         float64 `json:"avg"`
-	BaseVolume  float64 `json:"vol_cur"`
-	QuoteVolume float64 `json:"vol"`
-	Last        float64 `json:"last"`
-	Buy         float64 `json:"buy"`
-	Sell        float64 `json:"sell"`
+	BaseVolume  Decimal `json:"vol_cur"`
+	QuoteVolume Decimal `json:"vol"`
+	Last        Decimal `json:"last"`
+	Buy         Decimal `json:"buy"`
+	Sell        Decimal `json:"sell"`
 	Updated     int     `json:"updated"`
 }